@@ -0,0 +1,23 @@
+// Equivalent of the golang asset for Windows cells and the .NET Core
+// buildpack. It answers on / like the golang asset and reports which
+// instance served the request on /instance.
+var builder = WebApplication.CreateBuilder(args);
+var app = builder.Build();
+
+var port = Environment.GetEnvironmentVariable("PORT") ?? "8080";
+
+app.MapGet("/", () =>
+{
+    Console.WriteLine($"Received request {DateTime.Now}");
+    return "dotnet, world\n";
+});
+
+app.MapGet("/instance", () => Results.Json(new Dictionary<string, string?>
+{
+    ["index"] = Environment.GetEnvironmentVariable("CF_INSTANCE_INDEX"),
+    ["guid"] = Environment.GetEnvironmentVariable("CF_INSTANCE_GUID"),
+    ["port"] = port,
+}));
+
+Console.WriteLine($"Listening on {port}...");
+app.Run($"http://0.0.0.0:{port}");
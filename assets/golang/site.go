@@ -1,14 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 func main() {
 	http.HandleFunc("/", hello)
+	http.HandleFunc("/instance", instance)
+	http.HandleFunc("/session/set", setSession)
+	http.HandleFunc("/session/whoami", whoami)
+	http.HandleFunc("/ws", websocketEcho)
+	http.HandleFunc("/stream", stream)
+	http.HandleFunc("/status/", status)
+	http.HandleFunc("/bytes/", sizedBytes)
+	http.HandleFunc("/echo", echo)
 	port := os.Getenv("PORT")
 	fmt.Printf("Listening on %s...", port)
 	err := http.ListenAndServe(":"+port, nil)
@@ -21,3 +37,229 @@ func hello(res http.ResponseWriter, req *http.Request) {
 	fmt.Println("Recieved request ", time.Now())
 	fmt.Fprintln(res, "go, world")
 }
+
+type instanceReport struct {
+	Index string `json:"index"`
+	Guid  string `json:"guid"`
+	Port  string `json:"port"`
+}
+
+type sessionReport struct {
+	instanceReport
+	Session string `json:"session"`
+}
+
+func currentInstance() instanceReport {
+	return instanceReport{
+		Index: os.Getenv("CF_INSTANCE_INDEX"),
+		Guid:  os.Getenv("CF_INSTANCE_GUID"),
+		Port:  os.Getenv("PORT"),
+	}
+}
+
+// Reports which app instance served the request.
+func instance(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(currentInstance())
+}
+
+// Starts a new session. Gorouter pins requests carrying the JSESSIONID
+// cookie to the instance that set it.
+func setSession(res http.ResponseWriter, req *http.Request) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	session := hex.EncodeToString(id)
+	http.SetCookie(res, &http.Cookie{Name: "JSESSIONID", Value: session, Path: "/"})
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(sessionReport{instanceReport: currentInstance(), Session: session})
+}
+
+// Reports the serving instance and the session the request carried, if any.
+func whoami(res http.ResponseWriter, req *http.Request) {
+	report := sessionReport{instanceReport: currentInstance()}
+	if cookie, err := req.Cookie("JSESSIONID"); err == nil {
+		report.Session = cookie.Value
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(report)
+}
+
+// Writes ?chunks= numbered lines, flushing each one and sleeping ?delay=
+// between them, so first-byte latency shows whether the router buffers.
+func stream(res http.ResponseWriter, req *http.Request) {
+	chunks, err := queryInt(req, "chunks", 10)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	delay, err := queryDuration(req, "delay", 100*time.Millisecond)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/plain")
+	for i := 0; i < chunks; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		fmt.Fprintf(res, "chunk %d\n", i)
+		flusher.Flush()
+	}
+}
+
+// Responds to /status/<code> with that code after ?delay=, with a body of
+// ?size= bytes when given.
+func status(res http.ResponseWriter, req *http.Request) {
+	code, err := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/status/"))
+	if err != nil || code < 200 || code > 599 {
+		http.Error(res, "status code must be between 200 and 599", http.StatusBadRequest)
+		return
+	}
+	delay, err := queryDuration(req, "delay", 0)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	size, err := queryInt(req, "size", -1)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	time.Sleep(delay)
+	body := []byte(fmt.Sprintf("status %d\n", code))
+	if size >= 0 {
+		body = bytes.Repeat([]byte("x"), size)
+	}
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		res.WriteHeader(code)
+		return
+	}
+	res.Header().Set("Content-Type", "text/plain")
+	res.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	res.WriteHeader(code)
+	res.Write(body)
+}
+
+// Responds to /bytes/<n> with n bytes of a fixed pattern. The SHA-256 of the
+// body is sent up front in X-Content-Sha256.
+func sizedBytes(res http.ResponseWriter, req *http.Request) {
+	n, err := strconv.ParseInt(strings.TrimPrefix(req.URL.Path, "/bytes/"), 10, 64)
+	if err != nil || n < 0 {
+		http.Error(res, "byte count must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	// Hashing takes a pass of its own so the body never has to be held in memory.
+	hash := sha256.New()
+	io.Copy(hash, io.LimitReader(&pattern{}, n))
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Length", strconv.FormatInt(n, 10))
+	res.Header().Set("X-Content-Sha256", hex.EncodeToString(hash.Sum(nil)))
+	io.Copy(res, io.LimitReader(&pattern{}, n))
+}
+
+// pattern yields byte(i % 251) at offset i; the prime period keeps it from
+// lining up with buffer sizes.
+type pattern struct {
+	offset int64
+}
+
+func (p *pattern) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = byte(p.offset % 251)
+		p.offset++
+	}
+	return len(b), nil
+}
+
+type echoReport struct {
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Query      map[string][]string `json:"query"`
+	Headers    map[string][]string `json:"headers"`
+	Host       string              `json:"host"`
+	BodyLength int64               `json:"body_length"`
+	BodySha256 string              `json:"body_sha256"`
+	TLS        *tlsReport          `json:"tls"`
+}
+
+type tlsReport struct {
+	Version            uint16   `json:"version"`
+	CipherSuite        uint16   `json:"cipher_suite"`
+	ServerName         string   `json:"server_name"`
+	NegotiatedProtocol string   `json:"negotiated_protocol"`
+	PeerCertificates   []string `json:"peer_certificates"`
+}
+
+// Describes the request as the app received it. TLS is null unless the
+// connection to the app itself was TLS.
+func echo(res http.ResponseWriter, req *http.Request) {
+	hash := sha256.New()
+	length, err := io.Copy(hash, req.Body)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := echoReport{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.Query(),
+		Headers:    req.Header,
+		Host:       req.Host,
+		BodyLength: length,
+		BodySha256: hex.EncodeToString(hash.Sum(nil)),
+	}
+	if req.TLS != nil {
+		report.TLS = &tlsReport{
+			Version:            req.TLS.Version,
+			CipherSuite:        req.TLS.CipherSuite,
+			ServerName:         req.TLS.ServerName,
+			NegotiatedProtocol: req.TLS.NegotiatedProtocol,
+			PeerCertificates:   []string{},
+		}
+		for _, cert := range req.TLS.PeerCertificates {
+			report.TLS.PeerCertificates = append(report.TLS.PeerCertificates, cert.Subject.String())
+		}
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(report)
+}
+
+func queryInt(req *http.Request, name string, fallback int) (int, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+func queryDuration(req *http.Request, name string, fallback time.Duration) (time.Duration, error) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opContinuation = 0x0
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	maxMessageBytes = 1024 * 1024
+)
+
+// Echoes every websocket message back unchanged. The websocket-echo asset
+// covers pings and instance prefixes; this is only meant for smoke checks.
+func websocketEcho(res http.ResponseWriter, req *http.Request) {
+	conn, err := upgrade(res, req)
+	if err != nil {
+		fmt.Println("Error upgrading:", err)
+		return
+	}
+	defer conn.close()
+
+	for {
+		opcode, payload, err := conn.readMessage()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Printf("Closing websocket from %s: %s\n", req.RemoteAddr, err)
+			}
+			return
+		}
+
+		switch opcode {
+		case opPing:
+			err = conn.writeFrame(opPong, payload)
+		case opPong:
+		case opClose:
+			conn.writeFrame(opClose, payload)
+			return
+		default:
+			err = conn.writeFrame(opcode, payload)
+		}
+		if err != nil {
+			fmt.Printf("Closing websocket from %s: %s\n", req.RemoteAddr, err)
+			return
+		}
+	}
+}
+
+type wsConn struct {
+	rw     *bufio.ReadWriter
+	closer io.Closer
+}
+
+func upgrade(res http.ResponseWriter, req *http.Request) (*wsConn, error) {
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if key == "" || !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		http.Error(res, "not a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		http.Error(res, "websockets unsupported", http.StatusInternalServerError)
+		return nil, errors.New("response cannot be hijacked")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &wsConn{rw: rw, closer: netConn}, nil
+}
+
+func (c *wsConn) close() {
+	c.closer.Close()
+}
+
+// readMessage returns the next control frame, or the next data message with
+// its fragments joined.
+func (c *wsConn) readMessage() (byte, []byte, error) {
+	var (
+		messageOpcode byte
+		message       []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if opcode >= opClose {
+			return opcode, payload, nil
+		}
+		if opcode != opContinuation {
+			messageOpcode = opcode
+		}
+		message = append(message, payload...)
+		if len(message) > maxMessageBytes {
+			return 0, nil, errors.New("message too large")
+		}
+		if fin {
+			return messageOpcode, message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.rw, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.rw, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.rw, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > maxMessageBytes {
+		return false, 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
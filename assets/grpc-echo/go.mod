@@ -0,0 +1,8 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/grpc-echo
+
+go 1.14
+
+require (
+	google.golang.org/grpc v1.38.0
+	google.golang.org/protobuf v1.26.0
+)
@@ -0,0 +1,94 @@
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative echo/echo.proto
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/grpc-echo/echo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+var instanceIndex = os.Getenv("CF_INSTANCE_INDEX")
+
+// Serves the echo.Echo service from echo/echo.proto, plus server reflection,
+// over h2c on $PORT.
+func main() {
+	port := os.Getenv("PORT")
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		panic(err)
+	}
+
+	server := grpc.NewServer()
+	echo.RegisterEchoServer(server, echoServer{})
+	reflection.Register(server)
+
+	fmt.Printf("Listening on %s...\n", port)
+	err = server.Serve(listener)
+	if err != nil {
+		panic(err)
+	}
+}
+
+type echoServer struct {
+	echo.UnimplementedEchoServer
+}
+
+func response(message string, sequence int) *echo.EchoResponse {
+	return &echo.EchoResponse{
+		Message:       message,
+		InstanceIndex: instanceIndex,
+		Sequence:      int32(sequence),
+	}
+}
+
+func (echoServer) Unary(ctx context.Context, request *echo.EchoRequest) (*echo.EchoResponse, error) {
+	fmt.Printf("Unary: %s\n", request.Message)
+	return response(request.Message, 0), nil
+}
+
+func (echoServer) ServerStream(request *echo.EchoRequest, stream echo.Echo_ServerStreamServer) error {
+	fmt.Printf("ServerStream: %s\n", request.Message)
+	count := int(request.Count)
+	if count <= 0 {
+		count = 1
+	}
+	interval := time.Duration(request.IntervalMs) * time.Millisecond
+
+	for i := 0; i < count; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			}
+		}
+		if err := stream.Send(response(request.Message, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (echoServer) BidiStream(stream echo.Echo_BidiStreamServer) error {
+	for sequence := 0; ; sequence++ {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("BidiStream: %s\n", request.Message)
+		if err := stream.Send(response(request.Message, sequence)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Serves HTTP/1.1 and h2c with prior knowledge on $PORT, and answers every
+// request with the protocol it arrived over, in the body and the
+// X-Backend-Protocol header.
+func main() {
+	port := os.Getenv("PORT")
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: h2c.NewHandler(http.HandlerFunc(protocol), &http2.Server{}),
+	}
+
+	fmt.Printf("Listening on %s...\n", port)
+	err := server.ListenAndServe()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func protocol(res http.ResponseWriter, req *http.Request) {
+	fmt.Printf("%s request from %s\n", req.Proto, req.RemoteAddr)
+	res.Header().Set("X-Backend-Protocol", req.Proto)
+	fmt.Fprintln(res, req.Proto)
+}
@@ -0,0 +1,3 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/http10-backend
+
+go 1.14
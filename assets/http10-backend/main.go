@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+type requestReport struct {
+	Method     string `json:"method"`
+	Target     string `json:"target"`
+	Proto      string `json:"proto"`
+	Host       string `json:"host"`
+	Connection string `json:"connection"`
+	KeepAlive  string `json:"keep_alive"`
+}
+
+// A backend that only speaks HTTP/1.0: every response is HTTP/1.0 without a
+// Content-Length or keep-alive, so its end is marked by closing the
+// connection, whatever the request asked for. The body describes the
+// request as received.
+func main() {
+	port := os.Getenv("PORT")
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Listening on %s...\n", port)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Println("Error accepting:", err)
+			continue
+		}
+		go serve(conn)
+	}
+}
+
+func serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	req, err := http.ReadRequest(bufio.NewReader(conn))
+	if err != nil {
+		fmt.Fprint(conn, "HTTP/1.0 400 Bad Request\r\nContent-Type: text/plain\r\n\r\n"+err.Error()+"\n")
+		return
+	}
+	fmt.Printf("%s %s %s from %s\n", req.Method, req.RequestURI, req.Proto, conn.RemoteAddr())
+
+	report := requestReport{
+		Method:     req.Method,
+		Target:     req.RequestURI,
+		Proto:      req.Proto,
+		Host:       req.Host,
+		Connection: req.Header.Get("Connection"),
+		KeepAlive:  req.Header.Get("Keep-Alive"),
+	}
+
+	fmt.Fprint(conn, "HTTP/1.0 200 OK\r\nContent-Type: application/json\r\n\r\n")
+	json.NewEncoder(conn).Encode(report)
+}
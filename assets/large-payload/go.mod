@@ -0,0 +1,3 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/large-payload
+
+go 1.14
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const CHUNK_BYTES = 64 * 1024
+
+type uploadReport struct {
+	Bytes    int64  `json:"bytes"`
+	Sha256   string `json:"sha256"`
+	Duration string `json:"duration"`
+}
+
+// Generates and consumes bodies of any size without holding them in memory:
+//
+//	/download?bytes=N&seed=S  streams N bytes generated from seed S, with their
+//	                          sha256 in the X-Content-Sha256 trailer
+//	/upload                   reads the whole body and reports its size and sha256
+func main() {
+	http.HandleFunc("/download", download)
+	http.HandleFunc("/upload", upload)
+
+	port := os.Getenv("PORT")
+	fmt.Printf("Listening on %s...\n", port)
+	err := http.ListenAndServe(":"+port, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func download(res http.ResponseWriter, req *http.Request) {
+	total, err := strconv.ParseInt(req.URL.Query().Get("bytes"), 10, 64)
+	if err != nil || total < 0 {
+		http.Error(res, "invalid bytes", http.StatusBadRequest)
+		return
+	}
+	seed := uint64(1)
+	if value := req.URL.Query().Get("seed"); value != "" {
+		seed, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(res, "invalid seed", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// No Content-Length, so the response is chunked and can carry the trailer
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Trailer", "X-Content-Sha256")
+
+	start := time.Now()
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(res, hash), io.LimitReader(newPayload(seed), total))
+	if err != nil {
+		fmt.Printf("Download to %s failed after %d of %d bytes: %s\n", req.RemoteAddr, written, total, err)
+		return
+	}
+	res.Header().Set("X-Content-Sha256", hex.EncodeToString(hash.Sum(nil)))
+	fmt.Printf("Sent %d bytes to %s in %s\n", written, req.RemoteAddr, time.Since(start))
+}
+
+func upload(res http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" && req.Method != "PUT" {
+		http.Error(res, "upload with POST or PUT", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	hash := sha256.New()
+	read, err := io.Copy(hash, req.Body)
+	if err != nil {
+		fmt.Printf("Upload from %s failed after %d bytes: %s\n", req.RemoteAddr, read, err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("Received %d bytes from %s in %s\n", read, req.RemoteAddr, time.Since(start))
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(uploadReport{
+		Bytes:    read,
+		Sha256:   hex.EncodeToString(hash.Sum(nil)),
+		Duration: time.Since(start).String(),
+	})
+}
+
+// payload is an endless deterministic byte stream, generated with xorshift64
+// so that the same seed always yields the same content.
+type payload struct {
+	state  uint64
+	buffer []byte
+	chunk  []byte
+}
+
+func newPayload(seed uint64) *payload {
+	if seed == 0 {
+		seed = 1
+	}
+	return &payload{state: seed, buffer: make([]byte, CHUNK_BYTES)}
+}
+
+func (p *payload) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		if len(p.chunk) == 0 {
+			p.fill()
+		}
+		copied := copy(b[n:], p.chunk)
+		p.chunk = p.chunk[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+func (p *payload) fill() {
+	for i := 0; i < CHUNK_BYTES; i += 8 {
+		p.state ^= p.state << 13
+		p.state ^= p.state >> 7
+		p.state ^= p.state << 17
+		binary.LittleEndian.PutUint64(p.buffer[i:], p.state)
+	}
+	p.chunk = p.buffer
+}
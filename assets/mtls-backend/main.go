@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+var certFile = flag.String(
+	"cert",
+	os.Getenv("CF_INSTANCE_CERT"),
+	"The certificate the TLS listener serves. Defaults to the instance identity certificate.",
+)
+
+var keyFile = flag.String(
+	"key",
+	os.Getenv("CF_INSTANCE_KEY"),
+	"The key of the served certificate. Defaults to the instance identity key.",
+)
+
+var clientCAFile = flag.String(
+	"clientCA",
+	"",
+	"File holding the CA that client certificates must chain to. The CLIENT_CA environment variable may hold the PEM instead.",
+)
+
+type clientReport struct {
+	InstanceIndex string   `json:"instance_index"`
+	Subject       string   `json:"subject"`
+	Issuer        string   `json:"issuer"`
+	DNSNames      []string `json:"dns_names"`
+	XFCC          string   `json:"xfcc"`
+}
+
+// Serves TLS on $PORT and rejects clients that do not present a certificate
+// signed by the configured CA. Each response reports the verified client
+// certificate and the X-Forwarded-Client-Cert header of the request.
+func main() {
+	flag.Parse()
+
+	caPEM := []byte(os.Getenv("CLIENT_CA"))
+	if *clientCAFile != "" {
+		var err error
+		caPEM, err = ioutil.ReadFile(*clientCAFile)
+		if err != nil {
+			panic(err)
+		}
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		panic("no client CA certificates configured")
+	}
+
+	port := os.Getenv("PORT")
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: http.HandlerFunc(report),
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		},
+	}
+
+	fmt.Printf("Listening on %s...\n", port)
+	err := server.ListenAndServeTLS(*certFile, *keyFile)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func report(res http.ResponseWriter, req *http.Request) {
+	cert := req.TLS.PeerCertificates[0]
+	fmt.Printf("Request from %s presenting %s\n", req.RemoteAddr, cert.Subject)
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(clientReport{
+		InstanceIndex: os.Getenv("CF_INSTANCE_INDEX"),
+		Subject:       cert.Subject.String(),
+		Issuer:        cert.Issuer.String(),
+		DNSNames:      cert.DNSNames,
+		XFCC:          req.Header.Get("X-Forwarded-Client-Cert"),
+	})
+}
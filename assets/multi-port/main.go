@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Serves HTTP on $PORT and on every port listed, comma separated, in
+// ADDITIONAL_PORTS. Each response names the port that served it.
+func main() {
+	ports := []string{os.Getenv("PORT")}
+	for _, port := range strings.Split(os.Getenv("ADDITIONAL_PORTS"), ",") {
+		if port = strings.TrimSpace(port); port != "" {
+			ports = append(ports, port)
+		}
+	}
+
+	errs := make(chan error, len(ports))
+	for _, port := range ports {
+		go func(port string) {
+			fmt.Printf("Listening on %s...\n", port)
+			errs <- http.ListenAndServe(":"+port, servedBy(port))
+		}(port)
+	}
+	panic(<-errs)
+}
+
+func servedBy(port string) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Printf("Request from %s on port %s\n", req.RemoteAddr, port)
+		res.Header().Set("X-Served-By-Port", port)
+		fmt.Fprintf(res, "instance %s port %s\n", os.Getenv("CF_INSTANCE_INDEX"), port)
+	})
+}
@@ -0,0 +1,5 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/route-service
+
+go 1.14
+
+require golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	forwardedUrlHeader = "X-CF-Forwarded-Url"
+	signatureHeader    = "X-CF-Proxy-Signature"
+	metadataHeader     = "X-CF-Proxy-Metadata"
+	markerHeader       = "X-Route-Service-Instance"
+)
+
+// gorouter's default route_service_timeout.
+const signatureTimeout = 60 * time.Second
+
+var instanceIndex = os.Getenv("CF_INSTANCE_INDEX")
+
+// A route service that forwards every request to X-CF-Forwarded-Url. The
+// request it forwards and the response it returns both carry
+// X-Route-Service-Instance, so specs can tell the route service was on the
+// path.
+//
+// When ROUTE_SERVICES_SECRET is set to gorouter's route_services_secret, the
+// proxy signature is decrypted and requests whose signature is expired or was
+// issued for another URL are rejected, as gorouter would reject them on the
+// way back. Without it only the presence and encoding of the signature are
+// checked.
+func main() {
+	secret := os.Getenv("ROUTE_SERVICES_SECRET")
+	if secret != "" {
+		var err error
+		signatureCipher, err = newSignatureCipher(secret)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	port := os.Getenv("PORT")
+	fmt.Printf("Listening on %s...\n", port)
+	err := http.ListenAndServe(":"+port, http.HandlerFunc(forward))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func forward(res http.ResponseWriter, req *http.Request) {
+	target, err := url.Parse(req.Header.Get(forwardedUrlHeader))
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		http.Error(res, "missing or invalid "+forwardedUrlHeader, http.StatusBadRequest)
+		return
+	}
+	if err := checkSignature(req); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("Forwarding %s %s\n", req.Method, target)
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(out *http.Request) {
+			out.URL = target
+			out.Host = target.Host
+			out.Header.Set(markerHeader, instanceIndex)
+		},
+		ModifyResponse: func(backendRes *http.Response) error {
+			backendRes.Header.Set(markerHeader, instanceIndex)
+			return nil
+		},
+	}
+	proxy.ServeHTTP(res, req)
+}
+
+// signatureCipher decrypts X-CF-Proxy-Signature. It is nil when no route
+// services secret was given.
+var signatureCipher cipher.AEAD
+
+// gorouter derives its AES-128-GCM key from the secret with PBKDF2.
+func newSignatureCipher(secret string) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(secret), nil, 100000, 16, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type signature struct {
+	ForwardedUrl  string    `json:"forwarded_url"`
+	RequestedTime time.Time `json:"requested_time"`
+}
+
+type metadata struct {
+	Nonce []byte `json:"nonce"`
+}
+
+func checkSignature(req *http.Request) error {
+	if err := checkEncoded(req, signatureHeader); err != nil {
+		return err
+	}
+	if err := checkEncoded(req, metadataHeader); err != nil {
+		return err
+	}
+	if signatureCipher == nil {
+		return nil
+	}
+
+	var meta metadata
+	decoded, _ := base64.URLEncoding.DecodeString(req.Header.Get(metadataHeader))
+	if err := json.Unmarshal(decoded, &meta); err != nil {
+		return fmt.Errorf("malformed %s: %s", metadataHeader, err)
+	}
+	if len(meta.Nonce) != signatureCipher.NonceSize() {
+		return fmt.Errorf("malformed %s: nonce is %d bytes", metadataHeader, len(meta.Nonce))
+	}
+
+	var sig signature
+	decoded, _ = base64.URLEncoding.DecodeString(req.Header.Get(signatureHeader))
+	plain, err := signatureCipher.Open(nil, meta.Nonce, decoded, []byte{})
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", signatureHeader, err)
+	}
+	if err := json.Unmarshal(plain, &sig); err != nil {
+		return fmt.Errorf("malformed %s: %s", signatureHeader, err)
+	}
+
+	if sig.ForwardedUrl != req.Header.Get(forwardedUrlHeader) {
+		return fmt.Errorf("%s was issued for %s", signatureHeader, sig.ForwardedUrl)
+	}
+	if age := time.Since(sig.RequestedTime); age > signatureTimeout {
+		return errors.New(signatureHeader + " has expired")
+	}
+	return nil
+}
+
+func checkEncoded(req *http.Request, header string) error {
+	value := req.Header.Get(header)
+	if value == "" {
+		return fmt.Errorf("missing %s", header)
+	}
+	if _, err := base64.URLEncoding.DecodeString(value); err != nil {
+		return fmt.Errorf("malformed %s: %s", header, err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	TRICKLE_TICK          = 100 * time.Millisecond
+	DEFAULT_TRICKLE_BYTES = 1024
+)
+
+var instanceIndex = os.Getenv("CF_INSTANCE_INDEX")
+
+// Responds as slowly as asked:
+//
+//	/delay?ms=N                         waits N ms before sending headers
+//	/trickle?bytes_per_sec=N&bytes=M    sends M bytes (default 1024) at N bytes/s
+//
+// STARTUP_DELAY_MS holds back listening on $PORT, to exercise slow starts.
+func main() {
+	if value := os.Getenv("STARTUP_DELAY_MS"); value != "" {
+		startupDelay, err := strconv.Atoi(value)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("Delaying startup by %dms\n", startupDelay)
+		time.Sleep(time.Duration(startupDelay) * time.Millisecond)
+	}
+
+	http.HandleFunc("/delay", delay)
+	http.HandleFunc("/trickle", trickle)
+	http.HandleFunc("/", func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprintln(res, instanceIndex)
+	})
+
+	port := os.Getenv("PORT")
+	fmt.Printf("Listening on %s...\n", port)
+	err := http.ListenAndServe(":"+port, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func delay(res http.ResponseWriter, req *http.Request) {
+	ms, ok := intParam(res, req, "ms", 0)
+	if !ok {
+		return
+	}
+
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+	case <-req.Context().Done():
+		fmt.Printf("Client %s went away during a %dms delay\n", req.RemoteAddr, ms)
+		return
+	}
+	fmt.Fprintf(res, "%s delayed %dms\n", instanceIndex, ms)
+}
+
+func trickle(res http.ResponseWriter, req *http.Request) {
+	rate, ok := intParam(res, req, "bytes_per_sec", 0)
+	if !ok {
+		return
+	}
+	if rate <= 0 {
+		http.Error(res, "bytes_per_sec must be positive", http.StatusBadRequest)
+		return
+	}
+	total, ok := intParam(res, req, "bytes", DEFAULT_TRICKLE_BYTES)
+	if !ok {
+		return
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Length", strconv.Itoa(total))
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(TRICKLE_TICK)
+	defer ticker.Stop()
+
+	start := time.Now()
+	sent := 0
+	for sent < total {
+		select {
+		case <-ticker.C:
+		case <-req.Context().Done():
+			fmt.Printf("Client %s went away after %d of %d bytes\n", req.RemoteAddr, sent, total)
+			return
+		}
+
+		due := int(float64(rate) * time.Since(start).Seconds())
+		if due > total {
+			due = total
+		}
+		if due <= sent {
+			continue
+		}
+		if _, err := res.Write(bytes.Repeat([]byte("x"), due-sent)); err != nil {
+			return
+		}
+		flusher.Flush()
+		sent = due
+	}
+	fmt.Printf("Trickled %d bytes to %s in %s\n", total, req.RemoteAddr, time.Since(start))
+}
+
+// intParam reads a non-negative integer query parameter, answering 400 when
+// it is invalid.
+func intParam(res http.ResponseWriter, req *http.Request, name string, defaultValue int) (int, bool) {
+	value := req.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, true
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		http.Error(res, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_INTERVAL = time.Second
+	MAX_EVENT_BYTES  = 1024 * 1024
+)
+
+var instanceIndex = os.Getenv("CF_INSTANCE_INDEX")
+
+// Streams server-sent events until the client goes away. Each event carries
+// its sequence number, a timestamp and the instance index. The interval
+// query parameter sets the cadence (a duration, defaults to 1s) and size pads
+// each event's data to that many bytes.
+func main() {
+	http.HandleFunc("/", stream)
+	port := os.Getenv("PORT")
+	fmt.Printf("Listening on %s...\n", port)
+	err := http.ListenAndServe(":"+port, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func stream(res http.ResponseWriter, req *http.Request) {
+	interval := DEFAULT_INTERVAL
+	if value := req.URL.Query().Get("interval"); value != "" {
+		var err error
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			http.Error(res, "invalid interval", http.StatusBadRequest)
+			return
+		}
+	}
+
+	size := 0
+	if value := req.URL.Query().Get("size"); value != "" {
+		var err error
+		size, err = strconv.Atoi(value)
+		if err != nil || size < 0 || size > MAX_EVENT_BYTES {
+			http.Error(res, "invalid size", http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	fmt.Printf("Streaming to %s every %s\n", req.RemoteAddr, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for sequence := 0; ; sequence++ {
+		data := fmt.Sprintf("%d %s %s", sequence, time.Now().UTC().Format(time.RFC3339Nano), instanceIndex)
+		if padding := size - len(data) - 1; padding > 0 {
+			data += " " + strings.Repeat("x", padding)
+		}
+
+		_, err := fmt.Fprintf(res, "id: %d\nevent: tick\ndata: %s\n\n", sequence, data)
+		if err != nil {
+			fmt.Printf("Stopped streaming to %s: %s\n", req.RemoteAddr, err)
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-ticker.C:
+		case <-req.Context().Done():
+			fmt.Printf("Stopped streaming to %s after %d events\n", req.RemoteAddr, sequence+1)
+			return
+		}
+	}
+}
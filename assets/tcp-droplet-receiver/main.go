@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 const (
@@ -28,25 +30,45 @@ var serverId = flag.String(
 
 func main() {
 	flag.Parse()
-	// Listen for incoming connections.
+	// SIGUSR1 makes the server refuse new connections, so health checks
+	// against it fail while the process keeps running. SIGUSR2 undoes it.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	listener := listen()
+	for sig := range signals {
+		switch {
+		case sig == syscall.SIGUSR1 && listener != nil:
+			fmt.Printf("%s:Refusing connections on %s\n", *serverId, *serverAddress)
+			listener.Close()
+			listener = nil
+		case sig == syscall.SIGUSR2 && listener == nil:
+			listener = listen()
+		}
+	}
+}
+
+// Listens for incoming connections and serves them until the listener is closed.
+func listen() net.Listener {
 	listener, err := net.Listen(CONN_TYPE, *serverAddress)
 	if err != nil {
 		fmt.Println("Error listening:", err.Error())
 		os.Exit(1)
 	}
-	// Close the listener when the application closes.
-	defer listener.Close()
 	fmt.Printf("%s:Listening on %s\n", *serverId, *serverAddress)
-	for {
-		// Listen for an incoming connection.
-		conn, err := listener.Accept()
-		if err != nil {
-			fmt.Println("Error accepting: ", err.Error())
-			os.Exit(1)
+	go func() {
+		for {
+			// Listen for an incoming connection.
+			conn, err := listener.Accept()
+			if err != nil {
+				fmt.Println("Stopped accepting: ", err.Error())
+				return
+			}
+			// Handle connections in a new goroutine.
+			go handleRequest(conn)
 		}
-		// Handle connections in a new goroutine.
-		go handleRequest(conn)
-	}
+	}()
+	return listener
 }
 
 // Handles incoming requests.
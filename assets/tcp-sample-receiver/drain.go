@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	listenersLock sync.Mutex
+	listeners     []net.Listener
+	draining      bool
+
+	// Tracks connections that are still being served.
+	inFlight sync.WaitGroup
+)
+
+func trackListener(listener net.Listener) {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	listeners = append(listeners, listener)
+}
+
+func isDraining() bool {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	return draining
+}
+
+// drainOnSigterm closes every listener on SIGTERM so no new connections are
+// accepted, leaving in-flight ones to be finished by awaitInFlight.
+func drainOnSigterm() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	<-signals
+
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	fmt.Printf("%s:Draining, no longer accepting connections\n", *serverId)
+	draining = true
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// awaitInFlight waits up to the grace period for open connections to close.
+func awaitInFlight(gracePeriod time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		fmt.Printf("%s:Drained\n", *serverId)
+	case <-time.After(gracePeriod):
+		fmt.Printf("%s:Grace period of %s expired with connections still open\n", *serverId, gracePeriod)
+	}
+}
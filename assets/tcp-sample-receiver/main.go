@@ -2,18 +2,30 @@ package main
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	DEFAULT_ADDRESS   = "localhost:3333"
 	CONN_TYPE         = "tcp"
 	DEFAULT_SERVER_ID = "sample_server"
+
+	PREFIX_ECHO_MODE  = "prefix-echo"
+	PURE_ECHO_MODE    = "pure-echo"
+	DISCARD_MODE      = "discard"
+	FIXED_BANNER_MODE = "fixed-banner"
 )
 
 var serverAddress = flag.String(
@@ -28,19 +40,166 @@ var serverId = flag.String(
 	"The Server id that is echoed back for each message.",
 )
 
+var controlAddress = flag.String(
+	"controlAddress",
+	"",
+	"The host:port of an HTTP listener reporting connection counts, bytes and durations. Disabled when empty.",
+)
+
+var tlsCert = flag.String(
+	"tlsCert",
+	"",
+	"Certificate file for serving TLS instead of plain TCP. Requires tlsKey.",
+)
+
+var tlsKey = flag.String(
+	"tlsKey",
+	"",
+	"Key file of the TLS certificate.",
+)
+
+var tlsCA = flag.String(
+	"tlsCA",
+	"",
+	"CA file that client certificates are verified against.",
+)
+
+var requireClientCert = flag.Bool(
+	"requireClientCert",
+	false,
+	"Reject TLS clients that do not present a certificate signed by tlsCA.",
+)
+
+var proxyProtocol = flag.Bool(
+	"proxyProtocol",
+	false,
+	"Expect a PROXY protocol v1 or v2 header on each connection and echo the client address it reports.",
+)
+
+var responseDelay = flag.Duration(
+	"responseDelay",
+	0,
+	"How long to wait before answering each message.",
+)
+
+var responseSize = flag.Int(
+	"responseSize",
+	0,
+	"Pad each response with filler bytes up to this many bytes. Responses are never truncated.",
+)
+
+var bytesPerSecond = flag.Int(
+	"bytesPerSecond",
+	0,
+	"Throttle responses to this many bytes per second. Unthrottled when 0.",
+)
+
+var mode = flag.String(
+	"mode",
+	PREFIX_ECHO_MODE,
+	"How to answer messages: prefix-echo echoes them prefixed with the server id, pure-echo echoes them unchanged, discard reads and drops them, fixed-banner sends the banner on connect and in reply to every message.",
+)
+
+var banner = flag.String(
+	"banner",
+	"",
+	"The banner sent in fixed-banner mode. Defaults to the server id.",
+)
+
+var drainTimeout = flag.Duration(
+	"drainTimeout",
+	10*time.Second,
+	"How long in-flight connections may take to finish after SIGTERM.",
+)
+
+var (
+	currentConnections int64
+	totalConnections   int64
+	bytesIn            int64
+	bytesOut           int64
+
+	durationsLock sync.Mutex
+	durations     []time.Duration
+)
+
+// Durations only cover connections that have closed.
+type connectionCounts struct {
+	Current   int64           `json:"current"`
+	Total     int64           `json:"total"`
+	BytesIn   int64           `json:"bytes_in"`
+	BytesOut  int64           `json:"bytes_out"`
+	Durations []time.Duration `json:"durations"`
+}
+
 func main() {
 	flag.Parse()
+	switch *mode {
+	case PREFIX_ECHO_MODE, PURE_ECHO_MODE, DISCARD_MODE, FIXED_BANNER_MODE:
+	default:
+		fmt.Printf("Unknown mode %q\n", *mode)
+		os.Exit(1)
+	}
+	if *banner == "" {
+		*banner = *serverId
+	}
+	tlsConfig, err := loadTLSConfig()
+	if err != nil {
+		fmt.Println("Error loading TLS configuration:", err.Error())
+		os.Exit(1)
+	}
+	if *controlAddress != "" {
+		go launchControlServer(*controlAddress)
+	}
+	go drainOnSigterm()
 	addresses := strings.Split(*serverAddress, ",")
 	includeServerAddress := len(addresses) > 1
 	wg := sync.WaitGroup{}
 	for _, address := range addresses {
 		wg.Add(1)
-		go launchServer(address, includeServerAddress, &wg)
+		go launchServer(address, includeServerAddress, tlsConfig, &wg)
 	}
 	wg.Wait()
+	if isDraining() {
+		awaitInFlight(*drainTimeout)
+	}
+}
+
+// loadTLSConfig returns nil when the receiver serves plain TCP.
+func loadTLSConfig() (*tls.Config, error) {
+	if *tlsCert == "" && *tlsKey == "" {
+		if *requireClientCert || *tlsCA != "" {
+			return nil, fmt.Errorf("tlsCA and requireClientCert need tlsCert and tlsKey")
+		}
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(*tlsCert, *tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if *tlsCA != "" {
+		caPEM, err := ioutil.ReadFile(*tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates in %s", *tlsCA)
+		}
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if *requireClientCert {
+		if config.ClientCAs == nil {
+			return nil, fmt.Errorf("requireClientCert needs tlsCA")
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
 }
 
-func launchServer(address string, includeServerAddress bool, wg *sync.WaitGroup) {
+func launchServer(address string, includeServerAddress bool, tlsConfig *tls.Config, wg *sync.WaitGroup) {
 	// Listen for incoming connections.
 	listener, err := net.Listen(CONN_TYPE, address)
 	if err != nil {
@@ -49,23 +208,78 @@ func launchServer(address string, includeServerAddress bool, wg *sync.WaitGroup)
 	}
 	// Close the listener when the application closes.
 	defer listener.Close()
+	trackListener(listener)
 	fmt.Printf("%s:Listening on %s\n", *serverId, address)
 	for {
 		// Listen for an incoming connection.
 		conn, err := listener.Accept()
 		if err != nil {
-			fmt.Println("Error accepting: ", err.Error())
+			if !isDraining() {
+				fmt.Println("Error accepting: ", err.Error())
+			}
 			wg.Done()
+			return
 		}
 		// Handle connections in a new goroutine.
-		go handleRequest(conn, includeServerAddress, address)
+		inFlight.Add(1)
+		go handleRequest(conn, includeServerAddress, address, tlsConfig)
+	}
+}
+
+// Serves the connection counts as JSON on /connections.
+func launchControlServer(address string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/connections", func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		durationsLock.Lock()
+		closed := append([]time.Duration{}, durations...)
+		durationsLock.Unlock()
+		json.NewEncoder(res).Encode(connectionCounts{
+			Current:   atomic.LoadInt64(&currentConnections),
+			Total:     atomic.LoadInt64(&totalConnections),
+			BytesIn:   atomic.LoadInt64(&bytesIn),
+			BytesOut:  atomic.LoadInt64(&bytesOut),
+			Durations: closed,
+		})
+	})
+	fmt.Printf("%s:Control listening on %s\n", *serverId, address)
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		fmt.Println("Error listening on control address:", err.Error())
+		os.Exit(1)
 	}
 }
 
 // Handles incoming requests.
-func handleRequest(conn net.Conn, includeServerAddress bool, address string) {
+func handleRequest(conn net.Conn, includeServerAddress bool, address string, tlsConfig *tls.Config) {
+	atomic.AddInt64(&totalConnections, 1)
+	atomic.AddInt64(&currentConnections, 1)
+	defer atomic.AddInt64(&currentConnections, -1)
+	defer inFlight.Done()
+	defer recordDuration(time.Now())
 	// Close the connection when you're done with it.
 	defer conn.Close()
+	// The PROXY header precedes the TLS handshake, so it is read off the
+	// raw connection before TLS is layered on top.
+	clientAddr := ""
+	if *proxyProtocol {
+		proxied, err := readProxyHeader(conn)
+		if err != nil {
+			fmt.Println("Error reading PROXY header:", err.Error())
+			return
+		}
+		clientAddr = proxied.clientAddr
+		conn = proxied
+	}
+	if tlsConfig != nil {
+		conn = tls.Server(conn, tlsConfig)
+	}
+	if *mode == FIXED_BANNER_MODE {
+		if err := writeResponse(conn, []byte(*banner)); err != nil {
+			fmt.Println("Error on connection write:", err.Error())
+			return
+		}
+	}
 	// Make a buffer to hold incoming data.
 	buff := make([]byte, 1024)
 	// Continue to receive the data forever...
@@ -76,18 +290,76 @@ func handleRequest(conn net.Conn, includeServerAddress bool, address string) {
 			fmt.Println("Error on connection read:", err.Error())
 			return
 		}
-		var writeBuffer bytes.Buffer
-		writeBuffer.WriteString(*serverId)
-		if includeServerAddress {
-			writeBuffer.WriteString("(" + address + ")")
+		atomic.AddInt64(&bytesIn, int64(readBytes))
+		var response []byte
+		switch *mode {
+		case DISCARD_MODE:
+			continue
+		case PURE_ECHO_MODE:
+			response = buff[0:readBytes]
+		case FIXED_BANNER_MODE:
+			response = []byte(*banner)
+		default:
+			var writeBuffer bytes.Buffer
+			writeBuffer.WriteString(*serverId)
+			if includeServerAddress {
+				writeBuffer.WriteString("(" + address + ")")
+			}
+			if clientAddr != "" {
+				writeBuffer.WriteString("[" + clientAddr + "]")
+			}
+			writeBuffer.WriteString(":")
+			writeBuffer.Write(buff[0:readBytes])
+			fmt.Println(writeBuffer.String())
+			response = writeBuffer.Bytes()
 		}
-		writeBuffer.WriteString(":")
-		writeBuffer.Write(buff[0:readBytes])
-		fmt.Println(writeBuffer.String())
-		_, err = conn.Write(writeBuffer.Bytes())
+		err = writeResponse(conn, response)
 		if err != nil {
 			fmt.Println("Error on connection write:", err.Error())
 			return
 		}
 	}
 }
+
+func recordDuration(start time.Time) {
+	durationsLock.Lock()
+	defer durationsLock.Unlock()
+	durations = append(durations, time.Since(start))
+}
+
+// writeResponse applies the configured delay, padding and throttling.
+func writeResponse(conn net.Conn, response []byte) error {
+	time.Sleep(*responseDelay)
+	if padding := *responseSize - len(response); padding > 0 {
+		response = append(response, bytes.Repeat([]byte("x"), padding)...)
+	}
+	if *bytesPerSecond <= 0 {
+		n, err := conn.Write(response)
+		atomic.AddInt64(&bytesOut, int64(n))
+		return err
+	}
+
+	// Write a tenth of the rate every 100ms so throttled responses still
+	// trickle out smoothly.
+	chunkSize := *bytesPerSecond / 10
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	interval := time.Duration(chunkSize) * time.Second / time.Duration(*bytesPerSecond)
+	for len(response) > 0 {
+		n := chunkSize
+		if n > len(response) {
+			n = len(response)
+		}
+		written, err := conn.Write(response[:n])
+		atomic.AddInt64(&bytesOut, int64(written))
+		if err != nil {
+			return err
+		}
+		response = response[n:]
+		if len(response) > 0 {
+			time.Sleep(interval)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	proxyV1Prefix    = "PROXY "
+	proxyV1MaxLength = 107
+)
+
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxiedConn reads through the buffer that consumed the PROXY header, so
+// payload bytes sent in the same segment as the header are not lost.
+type proxiedConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	clientAddr string
+}
+
+func (c *proxiedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// readProxyHeader consumes a PROXY protocol v1 or v2 header from the start
+// of the connection and returns the client address it reports. Headers that
+// carry no address (v1 UNKNOWN, v2 LOCAL) report the peer address instead.
+func readProxyHeader(conn net.Conn) (*proxiedConn, error) {
+	reader := bufio.NewReader(conn)
+	proxied := &proxiedConn{Conn: conn, reader: reader, clientAddr: conn.RemoteAddr().String()}
+
+	signature, err := reader.Peek(len(proxyV1Prefix))
+	if err != nil {
+		return nil, err
+	}
+	if string(signature) == proxyV1Prefix {
+		return proxied, readProxyV1Header(proxied)
+	}
+
+	signature, err = reader.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(signature, proxyV2Signature) {
+		return proxied, readProxyV2Header(proxied)
+	}
+	return nil, fmt.Errorf("connection did not start with a PROXY protocol header")
+}
+
+// Parses "PROXY TCP4|TCP6 <src> <dst> <srcport> <dstport>\r\n".
+func readProxyV1Header(conn *proxiedConn) error {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return fmt.Errorf("PROXY v1 header longer than %d bytes", proxyV1MaxLength)
+		}
+		b, err := conn.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+		line = append(line, b)
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return fmt.Errorf("malformed PROXY v1 header %q", strings.TrimSpace(string(line)))
+	}
+	if net.ParseIP(fields[2]) == nil {
+		return fmt.Errorf("invalid PROXY v1 source address %q", fields[2])
+	}
+	if _, err := strconv.ParseUint(fields[4], 10, 16); err != nil {
+		return fmt.Errorf("invalid PROXY v1 source port %q", fields[4])
+	}
+	conn.clientAddr = net.JoinHostPort(fields[2], fields[4])
+	return nil
+}
+
+// Parses the 16 byte v2 preamble followed by the address block. Only the
+// source address of TCP over IPv4 and IPv6 is extracted; TLVs are skipped.
+func readProxyV2Header(conn *proxiedConn) error {
+	preamble := make([]byte, 16)
+	if _, err := io.ReadFull(conn.reader, preamble); err != nil {
+		return err
+	}
+	versionCommand := preamble[12]
+	family := preamble[13]
+	length := binary.BigEndian.Uint16(preamble[14:16])
+
+	if versionCommand>>4 != 2 {
+		return fmt.Errorf("unsupported PROXY protocol version %d", versionCommand>>4)
+	}
+	block := make([]byte, length)
+	if _, err := io.ReadFull(conn.reader, block); err != nil {
+		return err
+	}
+	if versionCommand&0x0F == 0 {
+		// LOCAL: the connection was made by the proxy itself.
+		return nil
+	}
+
+	var ipLength int
+	switch family {
+	case 0x11:
+		ipLength = net.IPv4len
+	case 0x21:
+		ipLength = net.IPv6len
+	default:
+		return nil
+	}
+	if len(block) < 2*ipLength+4 {
+		return fmt.Errorf("PROXY v2 address block too short for family 0x%02x", family)
+	}
+	ip := net.IP(block[:ipLength])
+	port := binary.BigEndian.Uint16(block[2*ipLength : 2*ipLength+2])
+	conn.clientAddr = net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+	return nil
+}
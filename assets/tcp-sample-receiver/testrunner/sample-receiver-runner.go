@@ -1,6 +1,9 @@
 package testrunner
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os/exec"
 	"time"
 
@@ -8,15 +11,71 @@ import (
 )
 
 type Args struct {
-	Address  string
-	ServerId string
+	Address           string
+	ServerId          string
+	ControlAddress    string
+	TLSCert           string
+	TLSKey            string
+	TLSCA             string
+	RequireClientCert bool
+	ProxyProtocol     bool
+	ResponseDelay     time.Duration
+	ResponseSize      int
+	BytesPerSecond    int
+	DrainTimeout      time.Duration
+	Mode              string
+	Banner            string
+}
+
+// ConnectionCounts is what the receiver reports on its control address.
+// Durations holds one entry per connection that has been closed.
+type ConnectionCounts struct {
+	Current   int64           `json:"current"`
+	Total     int64           `json:"total"`
+	BytesIn   int64           `json:"bytes_in"`
+	BytesOut  int64           `json:"bytes_out"`
+	Durations []time.Duration `json:"durations"`
 }
 
 func (args Args) ArgSlice() []string {
-	return []string{
+	argSlice := []string{
 		"-address=" + args.Address,
 		"-serverId=" + args.ServerId,
 	}
+	if args.ControlAddress != "" {
+		argSlice = append(argSlice, "-controlAddress="+args.ControlAddress)
+	}
+	if args.TLSCert != "" {
+		argSlice = append(argSlice, "-tlsCert="+args.TLSCert, "-tlsKey="+args.TLSKey)
+	}
+	if args.TLSCA != "" {
+		argSlice = append(argSlice, "-tlsCA="+args.TLSCA)
+	}
+	if args.RequireClientCert {
+		argSlice = append(argSlice, "-requireClientCert")
+	}
+	if args.ProxyProtocol {
+		argSlice = append(argSlice, "-proxyProtocol")
+	}
+	if args.ResponseDelay > 0 {
+		argSlice = append(argSlice, "-responseDelay="+args.ResponseDelay.String())
+	}
+	if args.ResponseSize > 0 {
+		argSlice = append(argSlice, fmt.Sprintf("-responseSize=%d", args.ResponseSize))
+	}
+	if args.BytesPerSecond > 0 {
+		argSlice = append(argSlice, fmt.Sprintf("-bytesPerSecond=%d", args.BytesPerSecond))
+	}
+	if args.DrainTimeout > 0 {
+		argSlice = append(argSlice, "-drainTimeout="+args.DrainTimeout.String())
+	}
+	if args.Mode != "" {
+		argSlice = append(argSlice, "-mode="+args.Mode)
+	}
+	if args.Banner != "" {
+		argSlice = append(argSlice, "-banner="+args.Banner)
+	}
+	return argSlice
 }
 
 func New(binPath string, args Args) *ginkgomon.Runner {
@@ -28,3 +87,23 @@ func New(binPath string, args Args) *ginkgomon.Runner {
 		Command:           exec.Command(binPath, args.ArgSlice()...),
 	})
 }
+
+// FetchConnectionCounts asks a receiver started with a control address how
+// many connections it is currently serving and has served in total, how many
+// bytes it has moved and how long its closed connections lasted.
+func FetchConnectionCounts(controlAddress string) (ConnectionCounts, error) {
+	var counts ConnectionCounts
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/connections", controlAddress))
+	if err != nil {
+		return counts, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return counts, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&counts)
+	return counts, err
+}
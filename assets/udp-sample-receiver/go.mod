@@ -0,0 +1,9 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/udp-sample-receiver
+
+go 1.14
+
+require (
+	github.com/onsi/ginkgo v1.2.0
+	github.com/onsi/gomega v0.0.0-20150831193734-6331bf5a5b5e
+	github.com/tedsuo/ifrit v0.0.0-20150410161953-65ca48cd8a94
+)
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	DEFAULT_ADDRESS   = "localhost:3333"
+	CONN_TYPE         = "udp"
+	DEFAULT_SERVER_ID = "sample_server"
+	MAX_DATAGRAM_SIZE = 65507
+)
+
+var serverAddress = flag.String(
+	"address",
+	DEFAULT_ADDRESS,
+	"Comma separated addresses in host:port format that the server will bind to.",
+)
+
+var serverId = flag.String(
+	"serverId",
+	DEFAULT_SERVER_ID,
+	"The Server id that is echoed back for each datagram.",
+)
+
+func main() {
+	flag.Parse()
+	addresses := strings.Split(*serverAddress, ",")
+	includeServerAddress := len(addresses) > 1
+	wg := sync.WaitGroup{}
+	for _, address := range addresses {
+		wg.Add(1)
+		go launchServer(address, includeServerAddress, &wg)
+	}
+	wg.Wait()
+}
+
+// Answers every datagram with one prefixed by the server id, sent back to
+// the address it came from.
+func launchServer(address string, includeServerAddress bool, wg *sync.WaitGroup) {
+	defer wg.Done()
+	conn, err := net.ListenPacket(CONN_TYPE, address)
+	if err != nil {
+		fmt.Println("Error listening:", err.Error())
+		os.Exit(1)
+	}
+	defer conn.Close()
+	fmt.Printf("%s:Listening on %s\n", *serverId, address)
+
+	buff := make([]byte, MAX_DATAGRAM_SIZE)
+	for {
+		readBytes, peer, err := conn.ReadFrom(buff)
+		if err != nil {
+			fmt.Println("Error on read:", err.Error())
+			return
+		}
+		var writeBuffer bytes.Buffer
+		writeBuffer.WriteString(*serverId)
+		if includeServerAddress {
+			writeBuffer.WriteString("(" + address + ")")
+		}
+		writeBuffer.WriteString(":")
+		writeBuffer.Write(buff[0:readBytes])
+		fmt.Println(writeBuffer.String())
+		_, err = conn.WriteTo(writeBuffer.Bytes(), peer)
+		if err != nil {
+			fmt.Println("Error on write to", peer, ":", err.Error())
+		}
+	}
+}
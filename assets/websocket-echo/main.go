@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	maxPayloadBytes = 16 * 1024 * 1024
+)
+
+var instanceIndex = os.Getenv("CF_INSTANCE_INDEX")
+
+// Echoes every websocket message back prefixed with the instance index.
+// Connect with ?ping_interval=<duration> to also receive pings from the
+// server at that interval.
+func main() {
+	http.HandleFunc("/", echo)
+	port := os.Getenv("PORT")
+	fmt.Printf("Listening on %s...\n", port)
+	err := http.ListenAndServe(":"+port, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func echo(res http.ResponseWriter, req *http.Request) {
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		fmt.Fprintf(res, "%s\n", instanceIndex)
+		return
+	}
+
+	var pingInterval time.Duration
+	if interval := req.URL.Query().Get("ping_interval"); interval != "" {
+		var err error
+		pingInterval, err = time.ParseDuration(interval)
+		if err != nil || pingInterval <= 0 {
+			http.Error(res, "invalid ping_interval", http.StatusBadRequest)
+			return
+		}
+	}
+
+	conn, err := upgrade(res, req)
+	if err != nil {
+		fmt.Println("Error upgrading:", err)
+		return
+	}
+	defer conn.close()
+	fmt.Printf("Websocket from %s\n", req.RemoteAddr)
+
+	done := make(chan struct{})
+	defer close(done)
+	if pingInterval > 0 {
+		go conn.pingEvery(pingInterval, done)
+	}
+
+	for {
+		opcode, payload, err := conn.readMessage()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Printf("Closing websocket from %s: %s\n", req.RemoteAddr, err)
+			}
+			return
+		}
+
+		switch opcode {
+		case opPing:
+			err = conn.writeFrame(opPong, payload)
+		case opPong:
+			fmt.Printf("Pong from %s: %s\n", req.RemoteAddr, payload)
+		case opClose:
+			conn.writeFrame(opClose, payload)
+			return
+		default:
+			err = conn.writeFrame(opcode, append([]byte(instanceIndex+":"), payload...))
+		}
+		if err != nil {
+			fmt.Printf("Closing websocket from %s: %s\n", req.RemoteAddr, err)
+			return
+		}
+	}
+}
+
+type wsConn struct {
+	rw         *bufio.ReadWriter
+	closer     io.Closer
+	writeMutex sync.Mutex
+}
+
+func upgrade(res http.ResponseWriter, req *http.Request) (*wsConn, error) {
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if key == "" || !strings.Contains(strings.ToLower(req.Header.Get("Connection")), "upgrade") {
+		http.Error(res, "not a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		http.Error(res, "websockets unsupported", http.StatusInternalServerError)
+		return nil, errors.New("response cannot be hijacked")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &wsConn{rw: rw, closer: netConn}, nil
+}
+
+func (c *wsConn) close() {
+	c.closer.Close()
+}
+
+func (c *wsConn) pingEvery(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := c.writeFrame(opPing, []byte(now.UTC().Format(time.RFC3339Nano))); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// readMessage returns the next control frame, or the next data message with
+// its fragments joined.
+func (c *wsConn) readMessage() (byte, []byte, error) {
+	var (
+		messageOpcode byte
+		message       []byte
+	)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		if opcode >= opClose {
+			return opcode, payload, nil
+		}
+		if opcode != opContinuation {
+			messageOpcode = opcode
+		}
+		message = append(message, payload...)
+		if len(message) > maxPayloadBytes {
+			return 0, nil, errors.New("message too large")
+		}
+		if fin {
+			return messageOpcode, message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.rw, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.rw, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.rw, extended); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > maxPayloadBytes {
+		return false, 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
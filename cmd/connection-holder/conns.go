@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+type tcpConn struct {
+	net.Conn
+	buffer []byte
+}
+
+func dialTcp(address string, timeout time.Duration) (heldConn, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpConn{Conn: conn, buffer: make([]byte, 1024)}, nil
+}
+
+func (c *tcpConn) heartbeat(payload []byte, deadline time.Time) error {
+	c.SetDeadline(deadline)
+	if _, err := c.Write(payload); err != nil {
+		return err
+	}
+	_, err := c.Read(c.buffer)
+	return err
+}
+
+type websocketConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebsocket performs the opening handshake itself, since the holder
+// only needs unfragmented text frames.
+func dialWebsocket(rawURL string, timeout time.Duration) (heldConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: timeout}
+	switch u.Scheme {
+	case "ws":
+		conn, err = dialer.Dial("tcp", host)
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: *skipSSLValidation})
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed with status %d", res.StatusCode)
+	}
+	return &websocketConn{Conn: conn, reader: reader}, nil
+}
+
+// heartbeat sends a masked text frame and waits for the next data frame,
+// answering any pings that arrive first.
+func (c *websocketConn) heartbeat(payload []byte, deadline time.Time) error {
+	c.SetDeadline(deadline)
+	if err := c.writeFrame(0x1, payload); err != nil {
+		return err
+	}
+	for {
+		opcode, data, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case 0x8:
+			return errors.New("websocket closed by server")
+		case 0x9:
+			if err := c.writeFrame(0xA, data); err != nil {
+				return err
+			}
+		case 0xA:
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, 0x80|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+	default:
+		header = append(header, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+	}
+
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	masked := make([]byte, len(payload))
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+
+	_, err := c.Write(append(append(header, mask...), masked...))
+	return err
+}
+
+func (c *websocketConn) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return 0, nil, err
+	}
+	opcode := header[0] & 0x0F
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if length > 1024*1024 {
+		return 0, nil, errors.New("frame too large")
+	}
+
+	payload := make([]byte, length)
+	_, err := io.ReadFull(c.reader, payload)
+	return opcode, payload, err
+}
@@ -0,0 +1,201 @@
+// connection-holder opens a number of long-lived TCP or WebSocket
+// connections to a target and keeps exchanging heartbeats over them. Every
+// time a connection breaks it records when, reconnects, and records when the
+// connection was back, so specs can measure how long a drain, redeploy or
+// failure interrupted traffic. The report is written as JSON on exit.
+//
+//	connection-holder -target tcp.example.com:1024 -connections 20 -duration 5m -output drops.json
+//	connection-holder -protocol websocket -target ws://app.example.com/ws -output drops.json
+//
+// The backend is expected to answer each heartbeat, which the tcp sample
+// receiver and websocket echo assets do.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	tcpProtocol       = "tcp"
+	websocketProtocol = "websocket"
+)
+
+var (
+	target      = flag.String("target", "", "host:port to connect to, or a ws:// URL for the websocket protocol.")
+	protocol    = flag.String("protocol", tcpProtocol, "Either tcp or websocket.")
+	connections = flag.Int("connections", 10, "Number of connections to hold open.")
+	duration    = flag.Duration("duration", time.Minute, "How long to hold the connections. Interrupting the process ends it early.")
+	interval    = flag.Duration("interval", time.Second, "How often each connection sends a heartbeat.")
+	timeout     = flag.Duration("timeout", 5*time.Second, "How long to wait for a connection or a heartbeat reply before counting it as dropped.")
+	output      = flag.String("output", "", "File the JSON report is written to. Defaults to stdout.")
+
+	skipSSLValidation = flag.Bool("skipSSLValidation", false, "Skip certificate verification for wss:// targets.")
+)
+
+// Report is written to -output when the holder exits.
+type Report struct {
+	Target      string    `json:"target"`
+	Protocol    string    `json:"protocol"`
+	Connections int       `json:"connections"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Drops       []Drop    `json:"drops"`
+}
+
+// Drop is one interruption of one connection. ReconnectedAt is nil when the
+// connection never came back before the holder exited.
+type Drop struct {
+	Connection    int        `json:"connection"`
+	DroppedAt     time.Time  `json:"dropped_at"`
+	ReconnectedAt *time.Time `json:"reconnected_at"`
+	Error         string     `json:"error"`
+}
+
+// heldConn sends a heartbeat and waits for any reply to it.
+type heldConn interface {
+	heartbeat(payload []byte, deadline time.Time) error
+	Close() error
+}
+
+type recorder struct {
+	sync.Mutex
+	drops []Drop
+}
+
+func (r *recorder) dropped(connection int, err error) int {
+	r.Lock()
+	defer r.Unlock()
+	r.drops = append(r.drops, Drop{Connection: connection, DroppedAt: time.Now(), Error: err.Error()})
+	return len(r.drops) - 1
+}
+
+func (r *recorder) reconnected(drop int) {
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	r.drops[drop].ReconnectedAt = &now
+}
+
+func main() {
+	flag.Parse()
+	if *target == "" {
+		fmt.Fprintln(os.Stderr, "-target is required")
+		os.Exit(2)
+	}
+	var dial func() (heldConn, error)
+	switch *protocol {
+	case tcpProtocol:
+		dial = func() (heldConn, error) { return dialTcp(*target, *timeout) }
+	case websocketProtocol:
+		dial = func() (heldConn, error) { return dialWebsocket(*target, *timeout) }
+	default:
+		fmt.Fprintf(os.Stderr, "unknown protocol %q\n", *protocol)
+		os.Exit(2)
+	}
+
+	report := Report{
+		Target:      *target,
+		Protocol:    *protocol,
+		Connections: *connections,
+		StartedAt:   time.Now(),
+	}
+
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+		case <-time.After(*duration):
+		}
+		close(done)
+	}()
+
+	rec := &recorder{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < *connections; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hold(i, dial, rec, done)
+		}(i)
+	}
+	wg.Wait()
+
+	report.FinishedAt = time.Now()
+	report.Drops = rec.drops
+	if report.Drops == nil {
+		report.Drops = []Drop{}
+	}
+	if err := writeReport(report); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing report:", err)
+		os.Exit(1)
+	}
+}
+
+// hold keeps connection i alive until done is closed, reconnecting after
+// every failure. Failing to establish the first connection counts as a drop
+// at start-up.
+func hold(i int, dial func() (heldConn, error), rec *recorder, done <-chan struct{}) {
+	openDrop := -1
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	var conn heldConn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		if conn == nil {
+			var err error
+			conn, err = dial()
+			if err != nil {
+				conn = nil
+				if openDrop < 0 {
+					openDrop = rec.dropped(i, err)
+				}
+			} else if openDrop >= 0 {
+				rec.reconnected(openDrop)
+				openDrop = -1
+			}
+		}
+
+		if conn != nil {
+			payload := []byte(fmt.Sprintf("connection-%d:%d", i, time.Now().UnixNano()))
+			if err := conn.heartbeat(payload, time.Now().Add(*timeout)); err != nil {
+				conn.Close()
+				conn = nil
+				openDrop = rec.dropped(i, err)
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeReport(report Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = fmt.Println(string(data))
+		return err
+	}
+	return ioutil.WriteFile(*output, data, 0644)
+}
@@ -4,6 +4,18 @@ type Assets struct {
 	TcpDropletReceiver string
 	TcpSampleReceiver  string
 	TcpSampleGolang    string
+	WebsocketEcho      string
+	GrpcEcho           string
+	H2c                string
+	Sse                string
+	MtlsBackend        string
+	SlowResponder      string
+	LargePayload       string
+	MultiPort          string
+	DotnetCore         string
+	UdpSampleReceiver  string
+	RouteService       string
+	Http10Backend      string
 }
 
 func NewAssets() Assets {
@@ -11,5 +23,17 @@ func NewAssets() Assets {
 		TcpDropletReceiver: "../assets/tcp-droplet-receiver/",
 		TcpSampleReceiver:  "../assets/tcp-sample-receiver/",
 		TcpSampleGolang:    "../assets/golang/",
+		WebsocketEcho:      "../assets/websocket-echo/",
+		GrpcEcho:           "../assets/grpc-echo/",
+		H2c:                "../assets/h2c/",
+		Sse:                "../assets/sse/",
+		MtlsBackend:        "../assets/mtls-backend/",
+		SlowResponder:      "../assets/slow-responder/",
+		LargePayload:       "../assets/large-payload/",
+		MultiPort:          "../assets/multi-port/",
+		DotnetCore:         "../assets/dotnet-core/",
+		UdpSampleReceiver:  "../assets/udp-sample-receiver/",
+		RouteService:       "../assets/route-service/",
+		Http10Backend:      "../assets/http10-backend/",
 	}
 }
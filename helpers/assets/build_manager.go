@@ -0,0 +1,143 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BuiltAsset is a directory holding a compiled asset, pushable with the
+// binary buildpack and Command as the start command.
+type BuiltAsset struct {
+	Dir     string
+	Command string
+}
+
+// BuildManager compiles Go assets for Linux cells ahead of pushing them, so
+// staging skips the Go buildpack. Binaries are cached on disk keyed by a hash
+// of the asset's sources, the target architecture and the version of the go
+// tool building them, so parallel nodes and later runs on the same machine
+// reuse them.
+type BuildManager struct {
+	cacheDir string
+	goarch   string
+}
+
+// NewBuildManager builds binaries for goarch and caches them in cacheDir, or
+// in a directory under the system temp dir when cacheDir is empty.
+func NewBuildManager(cacheDir, goarch string) *BuildManager {
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "routing-acceptance-assets")
+	}
+	return &BuildManager{cacheDir: cacheDir, goarch: goarch}
+}
+
+// Build returns the cached build of assetDir, compiling it first when its
+// sources changed since the last build.
+func (m *BuildManager) Build(assetDir string) (BuiltAsset, error) {
+	name := filepath.Base(filepath.Clean(assetDir))
+	asset := BuiltAsset{Command: "./" + name}
+
+	goVersion, err := goToolVersion()
+	if err != nil {
+		return asset, err
+	}
+	hash, err := hashSources(assetDir, goVersion+"/"+m.goarch)
+	if err != nil {
+		return asset, err
+	}
+	asset.Dir = filepath.Join(m.cacheDir, fmt.Sprintf("%s-%s", name, hash[:16]))
+	if _, err := os.Stat(filepath.Join(asset.Dir, name)); err == nil {
+		return asset, nil
+	}
+
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return asset, err
+	}
+	// Build next to the cache entry and rename it into place, so a concurrent
+	// build of the same sources never exposes a partial binary.
+	buildDir, err := ioutil.TempDir(m.cacheDir, name+"-building")
+	if err != nil {
+		return asset, err
+	}
+	defer os.RemoveAll(buildDir)
+
+	absAssetDir, err := filepath.Abs(assetDir)
+	if err != nil {
+		return asset, err
+	}
+	cmd := exec.Command("go", "build", "-o", filepath.Join(buildDir, name), ".")
+	cmd.Dir = absAssetDir
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+m.goarch, "CGO_ENABLED=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return asset, fmt.Errorf("building %s failed: %s\n%s", assetDir, err, output)
+	}
+
+	if err := os.Rename(buildDir, asset.Dir); err != nil {
+		// Another node finished the same build first.
+		if _, statErr := os.Stat(filepath.Join(asset.Dir, name)); statErr == nil {
+			return asset, nil
+		}
+		return asset, err
+	}
+	return asset, nil
+}
+
+// goToolVersion is the version of the go tool on PATH, which builds the
+// assets, rather than the one the suite itself was compiled with.
+func goToolVersion() (string, error) {
+	output, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", fmt.Errorf("reading the go version failed: %s", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// hashSources digests the path and contents of every file under dir along
+// with toolchain, which names the go tool and target the binary is built
+// with.
+func hashSources(dir, toolchain string) (string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	io.WriteString(hash, toolchain)
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(hash, rel)
+
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,32 @@
+package assets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// PublishDotnet compiles a .NET asset into a self-contained directory for the
+// given runtime identifier (e.g. win-x64), ready to push with the binary
+// buildpack on stacks without the .NET Core buildpack. Callers remove the
+// returned directory when done.
+func PublishDotnet(sourceDir, runtime string) (string, error) {
+	outputDir, err := ioutil.TempDir("", "dotnet-asset")
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("dotnet", "publish", sourceDir,
+		"--configuration", "Release",
+		"--runtime", runtime,
+		"--self-contained",
+		"--output", outputDir,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.RemoveAll(outputDir)
+		return "", fmt.Errorf("dotnet publish failed: %s\n%s", err, output)
+	}
+	return outputDir, nil
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Capabilities the Routing API may or may not offer, depending on the
+// routing-release it comes from.
+const (
+	HttpRoutesCapability          = "http_routes"
+	RouterGroupCreationCapability = "router_group_creation"
+)
+
+// RoutingApiCapabilities records which capabilities the Routing API under test
+// offers. The Routing API has no version or feature endpoint, so each one is
+// discovered by probing the endpoint behind it with a request that cannot
+// change anything.
+type RoutingApiCapabilities map[string]bool
+
+type capabilityProbe struct {
+	method string
+	path   string
+	body   string
+	// supported tells an implemented endpoint apart by its status code
+	supported func(statusCode int) bool
+}
+
+var capabilityProbes = map[string]capabilityProbe{
+	HttpRoutesCapability: {
+		method:    "GET",
+		path:      "/routing/v1/routes",
+		supported: func(statusCode int) bool { return statusCode == http.StatusOK },
+	},
+	// An empty router group fails validation where creation is implemented
+	RouterGroupCreationCapability: {
+		method: "POST",
+		path:   "/routing/v1/router_groups",
+		body:   "{}",
+		supported: func(statusCode int) bool {
+			return statusCode == http.StatusBadRequest
+		},
+	},
+}
+
+func DiscoverRoutingApiCapabilities(conf RoutingConfig, token string) RoutingApiCapabilities {
+	httpClient, baseUrl := NewRoutingApiHttpClient(conf)
+
+	capabilities := RoutingApiCapabilities{}
+	for name, probe := range capabilityProbes {
+		req, err := http.NewRequest(probe.method, baseUrl+probe.path, bytes.NewReader([]byte(probe.body)))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := httpClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		capabilities[name] = probe.supported(resp.StatusCode)
+	}
+	return capabilities
+}
+
+// Require skips the current spec unless the Routing API offers capability.
+func (c RoutingApiCapabilities) Require(capability string) {
+	if !c[capability] {
+		Skip("Skipping this test because the Routing API does not offer " + capability + ".")
+	}
+}
@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+// PushDockerAppNoStart pushes a published image as an app without starting
+// it, mirroring PushAppNoStart for buildpack apps.
+func PushDockerAppNoStart(appName, image string, timeout time.Duration, args ...string) {
+	pushArgs := append([]string{"push", appName, "--docker-image", image, "--no-start"}, args...)
+	Expect(cf.Cf(pushArgs...).Wait(timeout)).To(Exit(0))
+}
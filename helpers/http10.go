@@ -0,0 +1,14 @@
+package helpers
+
+import "time"
+
+// Http10Get sends "GET target HTTP/1.0" to address with exactly the given
+// header lines, e.g. "Host: app.example.com". Unlike net/http it adds no
+// Host, Connection or User-Agent header of its own, so it can send the
+// requests legacy clients send. An HTTP/1.0 server without keep-alive must
+// answer with ServerClosed set.
+func Http10Get(address, target string, timeout time.Duration, headerLines ...string) (*RawResponse, error) {
+	request := NewRawRequest("GET", target, "HTTP/1.0")
+	request.HeaderLines = append(request.HeaderLines, headerLines...)
+	return SendRawRequest(address, request, nil, timeout)
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import "time"
+
+// LoadProfile describes how the number of concurrent clients of a perf
+// scenario changes over its Duration seconds. Without ramp or spikes it is
+// a steady Concurrency clients throughout.
+type LoadProfile struct {
+	Name        string `json:"name"`
+	Duration    int    `json:"duration"`
+	Concurrency int    `json:"concurrency"`
+
+	// RampUp seconds raise the clients linearly to Concurrency, in steps
+	// of StepSize clients when it is set.
+	RampUp   int `json:"ramp_up"`
+	StepSize int `json:"step_size"`
+
+	// Every SpikeInterval seconds, SpikeConcurrency extra clients join for
+	// the last SpikeDuration seconds of the interval.
+	SpikeConcurrency int `json:"spike_concurrency"`
+	SpikeDuration    int `json:"spike_duration"`
+	SpikeInterval    int `json:"spike_interval"`
+
+	// MaxConcurrency caps the clients at any time, spikes included.
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+// ConcurrencyAt returns how many clients should be running elapsed into the
+// scenario.
+func (p LoadProfile) ConcurrencyAt(elapsed time.Duration) int {
+	clients := p.Concurrency
+
+	rampUp := time.Duration(p.RampUp) * time.Second
+	if elapsed < rampUp {
+		clients = int(int64(p.Concurrency) * int64(elapsed) / int64(rampUp))
+		if p.StepSize > 0 {
+			clients -= clients % p.StepSize
+			if clients < p.StepSize {
+				clients = p.StepSize
+			}
+		}
+		if clients > p.Concurrency {
+			clients = p.Concurrency
+		}
+		if clients < 1 {
+			clients = 1
+		}
+	}
+
+	if p.SpikeConcurrency > 0 && p.SpikeInterval > 0 {
+		interval := time.Duration(p.SpikeInterval) * time.Second
+		spike := time.Duration(p.SpikeDuration) * time.Second
+		if elapsed%interval >= interval-spike {
+			clients += p.SpikeConcurrency
+		}
+	}
+
+	if p.MaxConcurrency > 0 && clients > p.MaxConcurrency {
+		clients = p.MaxConcurrency
+	}
+	return clients
+}
+
+// Workers is the most clients the profile ever runs at once.
+func (p LoadProfile) Workers() int {
+	workers := p.Concurrency + p.SpikeConcurrency
+	if p.MaxConcurrency > 0 && workers > p.MaxConcurrency {
+		workers = p.MaxConcurrency
+	}
+	return workers
+}
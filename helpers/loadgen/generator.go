@@ -0,0 +1,135 @@
+package loadgen
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// IdleWorkerInterval is how often a worker that Active holds back checks
+// again.
+const IdleWorkerInterval = 100 * time.Millisecond
+
+// Request sends one request and returns once its response arrived. It must
+// give up when ctx is done. Requests run on goroutines of their own, so they
+// report failures as errors rather than through test assertions.
+type Request func(ctx context.Context) error
+
+// Pool is a closed-loop load generator: Workers goroutines each send requests
+// back-to-back, or paced by Interval, for Duration after an unrecorded
+// WarmUp.
+type Pool struct {
+	Workers  int
+	Duration time.Duration
+	WarmUp   time.Duration
+
+	// Interval, when set, paces every worker to start at most one request
+	// per Interval instead of sending them back-to-back.
+	Interval time.Duration
+
+	// NewWorker builds the request worker i sends. All workers are built
+	// before any of them start, so each can hold on to its own connection.
+	NewWorker func(worker int) Request
+
+	// Active, when set, lets worker i send requests only while it returns
+	// true for the time elapsed since the warm-up ended, which is zero
+	// throughout the warm-up.
+	Active func(worker int, elapsed time.Duration) bool
+}
+
+// Run drives the load until Duration passes or ctx is done, and returns what
+// it recorded after the warm-up. Requests in flight when Duration passes are
+// allowed to finish; those in flight when ctx is done are cancelled. The
+// context requests get is cancelled once Run returns, which closes the
+// connections workers hold, so they do not leak into the next run.
+func (p Pool) Run(ctx context.Context) Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	requests := make([]Request, p.Workers)
+	for i := range requests {
+		requests[i] = p.NewWorker(i)
+	}
+
+	recorder := NewRecorder()
+	start := time.Now()
+	deadline := start.Add(p.WarmUp + p.Duration)
+
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(worker int, request Request) {
+			defer wg.Done()
+
+			for now := time.Now(); ctx.Err() == nil && now.Before(deadline); now = time.Now() {
+				elapsed := now.Sub(start) - p.WarmUp
+				if elapsed < 0 {
+					elapsed = 0
+				}
+				if p.Active != nil && !p.Active(worker, elapsed) {
+					sleep(ctx, IdleWorkerInterval)
+					continue
+				}
+				recorder.Time(func() error { return request(ctx) })
+				if p.Interval > 0 {
+					sleep(ctx, p.Interval-time.Since(now))
+				}
+			}
+		}(i, request)
+	}
+
+	if p.WarmUp > 0 && sleep(ctx, p.WarmUp) {
+		recorder.Reset()
+	}
+	wg.Wait()
+	return recorder.Finish()
+}
+
+// RunRate is an open-loop load generator: it starts rate requests per second
+// for duration, after an unrecorded warm-up at the same rate, without waiting
+// for earlier ones to finish. A slow server therefore shows up as latency
+// rather than as a lower request rate. Each call gets the sequence number of
+// its request.
+func RunRate(ctx context.Context, rate int, duration, warmUp time.Duration, request func(ctx context.Context, i int) error) Result {
+	var wg sync.WaitGroup
+	recorder := NewRecorder()
+
+	warmUpEnd := time.After(warmUp)
+	end := time.NewTimer(warmUp + duration)
+	defer end.Stop()
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	for i := 0; ; {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return recorder.Finish()
+		case <-end.C:
+			wg.Wait()
+			return recorder.Finish()
+		case <-warmUpEnd:
+			recorder.Reset()
+			warmUpEnd = nil
+		case <-ticker.C:
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				recorder.Time(func() error { return request(ctx, i) })
+			}(i)
+			i++
+		}
+	}
+}
+
+// sleep waits for d and reports whether it did so before ctx was done.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
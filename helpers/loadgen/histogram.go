@@ -0,0 +1,127 @@
+package loadgen
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+	bucketCount    = 64 - subBucketBits
+)
+
+// Histogram records latencies in the layout of an HDR histogram: every power
+// of two range of nanoseconds is split into 64 equal sub-buckets, so any
+// recorded value is kept to within 1.6% in constant memory, however many
+// requests a run sends.
+type Histogram struct {
+	counts [subBucketCount + (bucketCount-1)*subBucketHalf]int64
+	total  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+func (h *Histogram) Record(latency time.Duration) {
+	if latency < 0 {
+		latency = 0
+	}
+	h.counts[countsIndex(uint64(latency))]++
+	if h.total == 0 || latency < h.min {
+		h.min = latency
+	}
+	if latency > h.max {
+		h.max = latency
+	}
+	h.total++
+	h.sum += latency
+}
+
+// Merge adds everything other recorded to h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.total == 0 {
+		return
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	if h.total == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.total += other.total
+	h.sum += other.sum
+}
+
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// ValueAtPercentile returns the latency below which p (between 0 and 1) of
+// the recorded values fall, using the nearest-rank method. It reports the
+// upper end of the sub-bucket holding that rank, never more than Max.
+func (h *Histogram) ValueAtPercentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			value := time.Duration(highestEquivalentValue(i))
+			if value > h.max {
+				value = h.max
+			}
+			return value
+		}
+	}
+	return h.max
+}
+
+// countsIndex maps values below subBucketCount one to one, and each higher
+// power of two range onto its upper half of sub-buckets.
+func countsIndex(value uint64) int {
+	if value < subBucketCount {
+		return int(value)
+	}
+	shift := bits.Len64(value) - subBucketBits
+	return subBucketCount + (shift-1)*subBucketHalf + int(value>>uint(shift)) - subBucketHalf
+}
+
+func highestEquivalentValue(index int) uint64 {
+	if index < subBucketCount {
+		return uint64(index)
+	}
+	shift := (index-subBucketCount)/subBucketHalf + 1
+	subBucket := uint64((index-subBucketCount)%subBucketHalf + subBucketHalf)
+	return (subBucket+1)<<uint(shift) - 1
+}
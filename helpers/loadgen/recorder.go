@@ -0,0 +1,86 @@
+package loadgen
+
+import (
+	"sync"
+	"time"
+)
+
+// Result holds what one load run observed. Latencies only include
+// successful requests.
+type Result struct {
+	Requests  int
+	Errors    int
+	Elapsed   time.Duration
+	Latencies *Histogram
+}
+
+func (r Result) Percentile(p float64) time.Duration {
+	return r.Latencies.ValueAtPercentile(p)
+}
+
+func (r Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// Throughput is in requests per second.
+func (r Result) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Recorder collects the outcome of requests timed from any number of
+// goroutines. Elapsed time counts from NewRecorder or the last Reset.
+type Recorder struct {
+	lock   sync.Mutex
+	start  time.Time
+	result Result
+}
+
+func NewRecorder() *Recorder {
+	r := &Recorder{}
+	r.Reset()
+	return r
+}
+
+// Reset discards everything recorded so far, e.g. at the end of a warm-up.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.start = time.Now()
+	r.result = Result{Latencies: NewHistogram()}
+}
+
+// Time runs request and records its outcome, unless it started before the
+// last Reset.
+func (r *Recorder) Time(request func() error) error {
+	requestStart := time.Now()
+	err := request()
+	latency := time.Since(requestStart)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if requestStart.Before(r.start) {
+		return err
+	}
+	r.result.Requests++
+	if err != nil {
+		r.result.Errors++
+	} else {
+		r.result.Latencies.Record(latency)
+	}
+	return err
+}
+
+// Finish returns what was recorded since the start.
+func (r *Recorder) Finish() Result {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	result := r.result
+	result.Elapsed = time.Since(r.start)
+	return result
+}
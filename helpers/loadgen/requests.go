@@ -0,0 +1,108 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPGet returns a Request getting url with client. It reads the whole body,
+// so the connection can be reused, and fails on any status other than 200.
+func HTTPGet(client *http.Client, url string) Request {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// TCPEcho returns a Request sending one message to an echoing server over a
+// held connection and waiting for the reply. The connection is redialled
+// after any failure, and closed once the ctx it was dialled with is done, so
+// it is held for as long as the caller's context lives. Its Requests must
+// not be shared between workers.
+func TCPEcho(address string, connectTimeout, rwTimeout time.Duration) Request {
+	var (
+		conn   net.Conn
+		closed chan struct{}
+	)
+	buff := make([]byte, 1024)
+
+	return func(ctx context.Context) error {
+		if conn == nil {
+			dialer := net.Dialer{Timeout: connectTimeout}
+			var err error
+			conn, err = dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				conn = nil
+				return err
+			}
+
+			closed = make(chan struct{})
+			go func(conn net.Conn, closed <-chan struct{}) {
+				select {
+				case <-ctx.Done():
+					conn.Close()
+				case <-closed:
+				}
+			}(conn, closed)
+		}
+
+		err := Exchange(conn, buff, rwTimeout)
+		if err != nil {
+			conn.Close()
+			close(closed)
+			conn = nil
+		}
+		return err
+	}
+}
+
+// TCPConnect returns a Request that dials address afresh, exchanges a single
+// message with the echoing server and closes the connection again.
+func TCPConnect(address string, connectTimeout, rwTimeout time.Duration) Request {
+	return func(ctx context.Context) error {
+		dialer := net.Dialer{Timeout: connectTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		return Exchange(conn, make([]byte, 1024), rwTimeout)
+	}
+}
+
+// Exchange writes a timestamped message to conn and reads the reply into buff.
+func Exchange(conn net.Conn, buff []byte, timeout time.Duration) error {
+	err := conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte(fmt.Sprintf("Time is %d", time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+	_, err = conn.Read(buff)
+	return err
+}
@@ -0,0 +1,97 @@
+package nats
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	natsclient "github.com/nats-io/nats.go"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	natsRegisterSubject   = "router.register"
+	natsUnregisterSubject = "router.unregister"
+)
+
+// Route is a route registration message in the format route-registrar
+// and the Diego route emitter publish.
+type Route struct {
+	Host                    string            `json:"host"`
+	Port                    uint16            `json:"port,omitempty"`
+	TLSPort                 uint16            `json:"tls_port,omitempty"`
+	Uris                    []string          `json:"uris"`
+	App                     string            `json:"app,omitempty"`
+	PrivateInstanceId       string            `json:"private_instance_id,omitempty"`
+	ServerCertDomainSAN     string            `json:"server_cert_domain_san,omitempty"`
+	RouteServiceUrl         string            `json:"route_service_url,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty"`
+	StaleThresholdInSeconds int               `json:"stale_threshold_in_seconds,omitempty"`
+}
+
+// Registrar registers routes with gorouter directly over NATS, bypassing
+// Cloud Controller and Diego.
+type Registrar struct {
+	conn *natsclient.Conn
+}
+
+func NewRegistrar(natsConfig *helpers.NatsConfig) *Registrar {
+	Expect(natsConfig).NotTo(BeNil(), "missing configuration nats")
+
+	conn, err := natsclient.Connect(
+		strings.Join(natsConfig.Servers, ","),
+		natsclient.UserInfo(natsConfig.User, natsConfig.Password),
+	)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &Registrar{conn: conn}
+}
+
+func (r *Registrar) Register(route Route) error {
+	return r.publish(natsRegisterSubject, route)
+}
+
+func (r *Registrar) Unregister(route Route) error {
+	return r.publish(natsUnregisterSubject, route)
+}
+
+// RegisterEvery re-registers routes at interval, as a route-registrar would,
+// until the returned stop function is called. Stopping does not unregister
+// the routes, so they are left for gorouter to prune.
+func (r *Registrar) RegisterEvery(interval time.Duration, routes ...Route) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, route := range routes {
+				r.Register(route)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (r *Registrar) Close() {
+	r.conn.Close()
+}
+
+// publish flushes after every message so callers can rely on gorouter having
+// been sent it once publish returns.
+func (r *Registrar) publish(subject string, route Route) error {
+	message, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	if err := r.conn.Publish(subject, message); err != nil {
+		return err
+	}
+	return r.conn.Flush()
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const perfMetricPrefix = "routing_perf"
+
+// PerfExportConfig names the time series databases the perf suite pushes
+// its results to, in addition to the files it writes.
+type PerfExportConfig struct {
+	InfluxDB    *InfluxDBConfig    `json:"influxdb"`
+	Pushgateway *PushgatewayConfig `json:"pushgateway"`
+}
+
+// InfluxDBConfig addresses an InfluxDB 1.x database, or an InfluxDB 2.x
+// bucket when Token is set.
+type InfluxDBConfig struct {
+	Url      string `json:"url"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Org      string `json:"org"`
+	Bucket   string `json:"bucket"`
+}
+
+// PushgatewayConfig addresses a Prometheus pushgateway. Each run replaces the
+// metrics of the previous one under Job.
+type PushgatewayConfig struct {
+	Url string `json:"url"`
+	Job string `json:"job"`
+}
+
+// PerfSample is what one perf scenario measured, keyed by metric name.
+type PerfSample struct {
+	Scenario string
+	Time     time.Time
+	Values   map[string]float64
+}
+
+// ExportPerfSamples pushes samples to every configured destination, labelled
+// with labels as well as their scenario.
+func ExportPerfSamples(conf *PerfExportConfig, labels map[string]string, samples []PerfSample) error {
+	if conf.InfluxDB != nil {
+		err := pushInfluxDB(conf.InfluxDB, labels, samples)
+		if err != nil {
+			return fmt.Errorf("pushing perf results to InfluxDB: %s", err)
+		}
+	}
+
+	if conf.Pushgateway != nil {
+		err := pushPushgateway(conf.Pushgateway, labels, samples)
+		if err != nil {
+			return fmt.Errorf("pushing perf results to the pushgateway: %s", err)
+		}
+	}
+	return nil
+}
+
+func pushInfluxDB(conf *InfluxDBConfig, labels map[string]string, samples []PerfSample) error {
+	// Line protocol has no empty tag values, so empty labels are left out.
+	var tags string
+	for _, name := range sortedLabelNames(labels) {
+		if labels[name] == "" {
+			continue
+		}
+		tags += fmt.Sprintf(",%s=%s", influxEscape(name), influxEscape(labels[name]))
+	}
+
+	var body bytes.Buffer
+	for _, sample := range samples {
+		var fields []string
+		for name, value := range sample.Values {
+			fields = append(fields, fmt.Sprintf("%s=%s", influxEscape(name), strconv.FormatFloat(value, 'f', -1, 64)))
+		}
+		sort.Strings(fields)
+		fmt.Fprintf(&body, "%s%s,scenario=%s %s %d\n", perfMetricPrefix, tags, influxEscape(sample.Scenario), strings.Join(fields, ","), sample.Time.UnixNano())
+	}
+
+	query := url.Values{"precision": {"ns"}}
+	path := "/write"
+	if conf.Token != "" {
+		path = "/api/v2/write"
+		query.Set("org", conf.Org)
+		query.Set("bucket", conf.Bucket)
+	} else {
+		query.Set("db", conf.Database)
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(conf.Url, "/")+path+"?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if conf.Token != "" {
+		req.Header.Set("Authorization", "Token "+conf.Token)
+	} else if conf.Username != "" {
+		req.SetBasicAuth(conf.Username, conf.Password)
+	}
+	return sendExport(req)
+}
+
+func pushPushgateway(conf *PushgatewayConfig, labels map[string]string, samples []PerfSample) error {
+	var pairs string
+	for _, name := range sortedLabelNames(labels) {
+		pairs += fmt.Sprintf(`%s="%s",`, name, promEscape(labels[name]))
+	}
+
+	// The text format wants all samples of a metric in one group.
+	byMetric := map[string][]string{}
+	var metrics []string
+	for _, sample := range samples {
+		for name, value := range sample.Values {
+			metric := perfMetricPrefix + "_" + name
+			if _, ok := byMetric[metric]; !ok {
+				metrics = append(metrics, metric)
+			}
+			byMetric[metric] = append(byMetric[metric], fmt.Sprintf(`%s{%sscenario="%s"} %s`, metric, pairs, promEscape(sample.Scenario), strconv.FormatFloat(value, 'g', -1, 64)))
+		}
+	}
+	sort.Strings(metrics)
+
+	var body bytes.Buffer
+	for _, metric := range metrics {
+		fmt.Fprintf(&body, "# TYPE %s gauge\n", metric)
+		for _, line := range byMetric[metric] {
+			fmt.Fprintln(&body, line)
+		}
+	}
+
+	req, err := http.NewRequest("PUT", strings.TrimSuffix(conf.Url, "/")+"/metrics/job/"+url.PathEscape(conf.Job), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	return sendExport(req)
+}
+
+func sendExport(req *http.Request) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// influxEscape escapes the characters line protocol gives a meaning to in
+// measurement, tag and field names and tag values.
+func influxEscape(s string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(s)
+}
+
+// promEscape escapes a label value for the Prometheus text format.
+func promEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func sortedLabelNames(labels map[string]string) []string {
+	var names []string
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+
+	. "github.com/onsi/gomega"
+)
+
+var (
+	buildManager     *assets.BuildManager
+	buildManagerOnce sync.Once
+)
+
+// PushGoAppNoStart pushes the Go asset in assetDir without starting it, as
+// PushAppNoStart does with the Go buildpack. command is the asset's binary,
+// named after its directory, followed by its arguments, or empty for the
+// asset's own start command.
+//
+// With Config.PrebuildAssets set the asset is compiled locally instead and
+// pushed with the binary buildpack. The binary is cached by source hash, so
+// it is built once and reused by every push, parallel node and later run on
+// the same machine.
+func PushGoAppNoStart(conf RoutingConfig, appName, assetDir, domain string, timeout time.Duration, memory, command string, args ...string) {
+	prebuild := conf.PrebuildAssets
+	if prebuild == nil {
+		if command != "" {
+			args = append([]string{"-c", command}, args...)
+		}
+		helpers.PushAppNoStart(appName, assetDir, conf.GoBuildpackName, domain, timeout, memory, args...)
+		return
+	}
+
+	buildManagerOnce.Do(func() {
+		buildManager = assets.NewBuildManager(prebuild.CacheDir, prebuild.Goarch)
+	})
+	built, err := buildManager.Build(assetDir)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	start := built.Command
+	if fields := strings.SplitN(command, " ", 2); len(fields) == 2 {
+		start += " " + fields[1]
+	}
+	args = append([]string{"-c", start}, args...)
+	helpers.PushAppNoStart(appName, built.Dir, prebuild.BinaryBuildpackName, domain, timeout, memory, args...)
+}
@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RawRequest is an HTTP request written to the wire exactly as built, for
+// conformance specs that need requests net/http would normalise: odd header
+// casing, folded headers, absolute-form targets or repeated headers.
+type RawRequest struct {
+	RequestLine string
+	HeaderLines []string
+	Body        []byte
+}
+
+// NewRawRequest starts a request with the given request line parts. The
+// target is sent verbatim, so "http://app.example.com/path" yields an
+// absolute-form request.
+func NewRawRequest(method, target, proto string) *RawRequest {
+	return &RawRequest{RequestLine: fmt.Sprintf("%s %s %s", method, target, proto)}
+}
+
+// Header appends "name: value" keeping the name's casing.
+func (r *RawRequest) Header(name, value string) *RawRequest {
+	r.HeaderLines = append(r.HeaderLines, name+": "+value)
+	return r
+}
+
+// FoldedHeader appends a header whose value continues over several lines
+// (obs-fold), each continuation starting with a space.
+func (r *RawRequest) FoldedHeader(name string, first string, continuations ...string) *RawRequest {
+	line := name + ": " + first
+	for _, continuation := range continuations {
+		line += "\r\n " + continuation
+	}
+	r.HeaderLines = append(r.HeaderLines, line)
+	return r
+}
+
+// WithBody sets the body without adding a Content-Length header.
+func (r *RawRequest) WithBody(body []byte) *RawRequest {
+	r.Body = body
+	return r
+}
+
+func (r *RawRequest) Bytes() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString(r.RequestLine + "\r\n")
+	for _, line := range r.HeaderLines {
+		buffer.WriteString(line + "\r\n")
+	}
+	buffer.WriteString("\r\n")
+	buffer.Write(r.Body)
+	return buffer.Bytes()
+}
+
+// RawResponse is a response read back for a RawRequest.
+type RawResponse struct {
+	Proto      string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// ServerClosed is true when the server closed the connection after the
+	// response.
+	ServerClosed bool
+}
+
+// SendRawRequest writes request to address, over TLS when tlsConfig is set,
+// and reads a single response.
+func SendRawRequest(address string, request *RawRequest, tlsConfig *tls.Config, timeout time.Duration) (*RawResponse, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(request.Bytes()); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	var httpRequest *http.Request
+	if strings.HasPrefix(request.RequestLine, "HEAD ") {
+		httpRequest = &http.Request{Method: http.MethodHead}
+	}
+	res, err := http.ReadResponse(reader, httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// A body without Content-Length already ran to EOF; otherwise give the
+	// server a moment to close the connection.
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = reader.ReadByte()
+	closed := err != nil
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		closed = false
+	}
+	return &RawResponse{
+		Proto:        res.Proto,
+		StatusCode:   res.StatusCode,
+		Header:       res.Header,
+		Body:         body,
+		ServerClosed: closed,
+	}, nil
+}
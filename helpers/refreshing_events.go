@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/routing-api"
+	uaaclient "code.cloudfoundry.org/uaa-go-client"
+)
+
+const resubscribeInterval = time.Second
+
+// RefreshingEventStream follows the http route events of the Routing API
+// across token expiry: whenever the stream ends it fetches a current token
+// and subscribes again, until its context is done.
+type RefreshingEventStream struct {
+	events        chan routing_api.Event
+	subscriptions int32
+
+	lock        sync.Mutex
+	unsubscribe context.CancelFunc
+}
+
+// NewRefreshingEventStream sets tokens from uaaClient on client, so client
+// should not be shared with specs that set their own token.
+func NewRefreshingEventStream(ctx context.Context, client routing_api.Client, uaaClient uaaclient.Client) *RefreshingEventStream {
+	stream := &RefreshingEventStream{events: make(chan routing_api.Event, 100)}
+	go stream.run(ctx, NewContextClient(client), client, uaaClient)
+	return stream
+}
+
+// Events is closed once the stream's context is done.
+func (s *RefreshingEventStream) Events() <-chan routing_api.Event {
+	return s.events
+}
+
+// Subscriptions counts the subscriptions made so far, including the first.
+func (s *RefreshingEventStream) Subscriptions() int {
+	return int(atomic.LoadInt32(&s.subscriptions))
+}
+
+// Interrupt ends the current subscription the way a dropped connection
+// would, so specs can make the stream resubscribe when they need it to.
+func (s *RefreshingEventStream) Interrupt() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+}
+
+func (s *RefreshingEventStream) run(ctx context.Context, contextClient ContextClient, client routing_api.Client, uaaClient uaaclient.Client) {
+	defer close(s.events)
+
+	for ctx.Err() == nil {
+		token, err := uaaClient.FetchToken(false)
+		if err == nil {
+			client.SetToken(token.AccessToken)
+
+			var source routing_api.EventSource
+			source, err = contextClient.SubscribeToEvents(ctx)
+			if err == nil {
+				subscriptionCtx, unsubscribe := context.WithCancel(ctx)
+				s.lock.Lock()
+				s.unsubscribe = unsubscribe
+				s.lock.Unlock()
+
+				atomic.AddInt32(&s.subscriptions, 1)
+				for event := range Events(subscriptionCtx, source) {
+					select {
+					case s.events <- event:
+					case <-ctx.Done():
+						unsubscribe()
+						return
+					}
+				}
+				unsubscribe()
+				continue
+			}
+		}
+
+		select {
+		case <-time.After(resubscribeInterval):
+		case <-ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package helpers
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PrometheusDebugFormat = "prometheus"
+	VarzDebugFormat       = "varz"
+)
+
+// RouterDebugEndpoint is an HTTP endpoint reporting the resource usage of a
+// gorouter or tcp_router instance. Prometheus endpoints are read for the
+// standard Go process metrics; varz endpoints are gorouter's status /varz,
+// which only reports CPU and memory.
+type RouterDebugEndpoint struct {
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	Format   string `json:"format"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ResourceSample is one reading of an endpoint. Values holds whichever of
+// cpu_percent, memory_bytes, goroutines and open_fds the endpoint reports.
+type ResourceSample struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]float64 `json:"values"`
+}
+
+// ResourceSampler reads every endpoint once per interval in the background
+// until it is stopped. Failed readings are skipped.
+type ResourceSampler struct {
+	endpoints []RouterDebugEndpoint
+	interval  time.Duration
+	client    *http.Client
+
+	lock    sync.Mutex
+	series  map[string][]ResourceSample
+	lastCPU map[string]cpuReading
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type cpuReading struct {
+	time    time.Time
+	seconds float64
+}
+
+func NewResourceSampler(endpoints []RouterDebugEndpoint, interval time.Duration, skipSSLValidation bool) *ResourceSampler {
+	return &ResourceSampler{
+		endpoints: endpoints,
+		interval:  interval,
+		client: &http.Client{
+			Timeout: interval,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipSSLValidation},
+			},
+		},
+		series:  map[string][]ResourceSample{},
+		lastCPU: map[string]cpuReading{},
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *ResourceSampler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.sampleAll()
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ResourceSampler) Stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// Series returns every sample taken so far, by endpoint name.
+func (s *ResourceSampler) Series() map[string][]ResourceSample {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	series := map[string][]ResourceSample{}
+	for name, samples := range s.series {
+		series[name] = append([]ResourceSample(nil), samples...)
+	}
+	return series
+}
+
+// Peaks returns the highest value of each metric sampled between from and
+// to, by endpoint name.
+func (s *ResourceSampler) Peaks(from, to time.Time) map[string]map[string]float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	peaks := map[string]map[string]float64{}
+	for name, samples := range s.series {
+		for _, sample := range samples {
+			if sample.Time.Before(from) || sample.Time.After(to) {
+				continue
+			}
+			if peaks[name] == nil {
+				peaks[name] = map[string]float64{}
+			}
+			for metric, value := range sample.Values {
+				if peak, ok := peaks[name][metric]; !ok || value > peak {
+					peaks[name][metric] = value
+				}
+			}
+		}
+	}
+	return peaks
+}
+
+func (s *ResourceSampler) sampleAll() {
+	var wg sync.WaitGroup
+	for _, endpoint := range s.endpoints {
+		wg.Add(1)
+		go func(endpoint RouterDebugEndpoint) {
+			defer wg.Done()
+
+			sample, err := s.sample(endpoint)
+			if err != nil {
+				return
+			}
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			s.series[endpoint.Name] = append(s.series[endpoint.Name], sample)
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+func (s *ResourceSampler) sample(endpoint RouterDebugEndpoint) (ResourceSample, error) {
+	req, err := http.NewRequest("GET", endpoint.Url, nil)
+	if err != nil {
+		return ResourceSample{}, err
+	}
+	if endpoint.Username != "" {
+		req.SetBasicAuth(endpoint.Username, endpoint.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ResourceSample{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ResourceSample{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	sample := ResourceSample{Time: time.Now()}
+	if endpoint.Format == VarzDebugFormat {
+		sample.Values, err = parseVarz(resp.Body)
+		return sample, err
+	}
+
+	var cpuSeconds float64
+	sample.Values, cpuSeconds, err = parsePrometheusProcessMetrics(resp.Body)
+	if err != nil {
+		return sample, err
+	}
+
+	// Prometheus only counts CPU seconds, so usage is the rate since the
+	// previous reading.
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if last, ok := s.lastCPU[endpoint.Name]; ok {
+		elapsed := sample.Time.Sub(last.time).Seconds()
+		sample.Values["cpu_percent"] = 100 * (cpuSeconds - last.seconds) / elapsed
+	}
+	s.lastCPU[endpoint.Name] = cpuReading{time: sample.Time, seconds: cpuSeconds}
+	return sample, nil
+}
+
+func parseVarz(body io.Reader) (map[string]float64, error) {
+	var varz struct {
+		Cpu float64 `json:"cpu"`
+		Mem float64 `json:"mem"`
+	}
+	err := json.NewDecoder(body).Decode(&varz)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]float64{
+		"cpu_percent":  varz.Cpu,
+		"memory_bytes": varz.Mem * 1024,
+	}, nil
+}
+
+var prometheusProcessMetrics = map[string]string{
+	"process_resident_memory_bytes": "memory_bytes",
+	"go_goroutines":                 "goroutines",
+	"process_open_fds":              "open_fds",
+}
+
+func parsePrometheusProcessMetrics(body io.Reader) (map[string]float64, float64, error) {
+	values := map[string]float64{}
+	var cpuSeconds float64
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+
+		if fields[0] == "process_cpu_seconds_total" {
+			cpuSeconds = value
+		} else if name, ok := prometheusProcessMetrics[fields[0]]; ok {
+			values[name] = value
+		}
+	}
+	return values, cpuSeconds, scanner.Err()
+}
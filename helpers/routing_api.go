@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/gomega"
+)
+
+// NewRoutingApiClient connects to the Routing API over mutual TLS when
+// routing_api_tls is configured, and through the public API otherwise.
+func NewRoutingApiClient(conf RoutingConfig) routing_api.Client {
+	if conf.RoutingApiTLS == nil {
+		return routing_api.NewClient(conf.RoutingApiUrl, conf.SkipSSLValidation)
+	}
+	return routing_api.NewClientWithTLSConfig(conf.RoutingApiTLS.ApiUrl, conf.RoutingApiTLS.ClientTLSConfig(true))
+}
+
+// NewRoutingApiHttpClient returns an http client and base url for sending raw
+// requests to the Routing API the same way NewRoutingApiClient connects.
+func NewRoutingApiHttpClient(conf RoutingConfig) (*http.Client, string) {
+	if conf.RoutingApiTLS == nil {
+		transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.SkipSSLValidation}}
+		return &http.Client{Transport: transport}, conf.RoutingApiUrl
+	}
+
+	transport := &http.Transport{TLSClientConfig: conf.RoutingApiTLS.ClientTLSConfig(true)}
+	return &http.Client{Transport: transport}, conf.RoutingApiTLS.ApiUrl
+}
+
+// ClientTLSConfig trusts the configured CA and, when withClientCert is set,
+// presents the configured client certificate.
+func (c RoutingApiTLSConfig) ClientTLSConfig(withClientCert bool) *tls.Config {
+	caPEM, err := ioutil.ReadFile(c.CACertFile)
+	Expect(err).ToNot(HaveOccurred())
+
+	caPool := x509.NewCertPool()
+	Expect(caPool.AppendCertsFromPEM(caPEM)).To(BeTrue(), "routing_api_tls.ca_cert_file contains no certificates")
+
+	tlsConfig := &tls.Config{RootCAs: caPool}
+	if withClientCert {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		Expect(err).ToNot(HaveOccurred())
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig
+}
+
+// UnusedExternalPorts returns count reservable ports of the router group that
+// currently have no tcp route mapping.
+func UnusedExternalPorts(client routing_api.Client, routerGroup models.RouterGroup, count int) []uint16 {
+	ranges, err := routerGroup.ReservablePorts.Parse()
+	Expect(err).ToNot(HaveOccurred())
+
+	mappings, err := client.TcpRouteMappings()
+	Expect(err).ToNot(HaveOccurred())
+
+	used := map[uint16]bool{}
+	for _, mapping := range mappings {
+		if mapping.RouterGroupGuid == routerGroup.Guid {
+			used[mapping.ExternalPort] = true
+		}
+	}
+
+	var ports []uint16
+	for _, r := range ranges {
+		start, end := r.Endpoints()
+		for port := start; port <= end && len(ports) < count; port++ {
+			if !used[uint16(port)] {
+				ports = append(ports, uint16(port))
+			}
+		}
+	}
+	Expect(ports).To(HaveLen(count), "Router group does not have enough unused reservable ports")
+	return ports
+}
@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// ContextClient bounds Routing API calls with a context, so a slow list or a
+// subscription that never connects fails the spec instead of hanging the
+// suite. The underlying client cannot be interrupted: a call abandoned on
+// cancellation keeps running in the background and its result is dropped,
+// and a subscription that connects after that is closed.
+type ContextClient struct {
+	client routing_api.Client
+}
+
+func NewContextClient(client routing_api.Client) ContextClient {
+	return ContextClient{client: client}
+}
+
+func (c ContextClient) TcpRouteMappings(ctx context.Context) ([]models.TcpRouteMapping, error) {
+	type result struct {
+		mappings []models.TcpRouteMapping
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		mappings, err := c.client.TcpRouteMappings()
+		results <- result{mappings, err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.mappings, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c ContextClient) UpsertTcpRouteMappings(ctx context.Context, mappings []models.TcpRouteMapping) error {
+	return withContext(ctx, func() error { return c.client.UpsertTcpRouteMappings(mappings) })
+}
+
+// SubscribeToEvents connects to the http route event stream. A source that
+// connects after ctx is done is closed.
+func (c ContextClient) SubscribeToEvents(ctx context.Context) (routing_api.EventSource, error) {
+	type result struct {
+		source routing_api.EventSource
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		source, err := c.client.SubscribeToEvents()
+		results <- result{source, err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.source, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-results; r.err == nil {
+				r.source.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// SubscribeToTcpEvents connects to the tcp route event stream. A source that
+// connects after ctx is done is closed.
+func (c ContextClient) SubscribeToTcpEvents(ctx context.Context) (routing_api.TcpEventSource, error) {
+	type result struct {
+		source routing_api.TcpEventSource
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		source, err := c.client.SubscribeToTcpEvents()
+		results <- result{source, err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.source, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-results; r.err == nil {
+				r.source.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Events streams events from source until ctx is done or the source fails.
+// The source is closed and the channel closed when the stream ends.
+func Events(ctx context.Context, source routing_api.EventSource) <-chan routing_api.Event {
+	events := make(chan routing_api.Event)
+	go func() {
+		defer close(events)
+		stop := closeOnDone(ctx, source.Close)
+		defer stop()
+
+		for {
+			event, err := source.Next()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// TcpEvents streams events from source until ctx is done or the source fails.
+// The source is closed and the channel closed when the stream ends.
+func TcpEvents(ctx context.Context, source routing_api.TcpEventSource) <-chan routing_api.TcpEvent {
+	events := make(chan routing_api.TcpEvent)
+	go func() {
+		defer close(events)
+		stop := closeOnDone(ctx, source.Close)
+		defer stop()
+
+		for {
+			event, err := source.Next()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+func withContext(ctx context.Context, fn func() error) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- fn()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeOnDone calls closeFn once ctx is done or the returned stop is called,
+// which unblocks a pending Next on an event source.
+func closeOnDone(ctx context.Context, closeFn func() error) func() {
+	stopped := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-ctx.Done():
+		case <-stopped:
+		}
+		closeFn()
+	}()
+	return func() {
+		close(stopped)
+		<-finished
+	}
+}
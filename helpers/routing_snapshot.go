@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/gomega"
+)
+
+// RoutingSnapshot is everything the Routing API serves to the routers, as
+// an operator would back it up before a disaster recovery drill.
+type RoutingSnapshot struct {
+	Routes           []models.Route           `json:"routes"`
+	RouterGroups     []models.RouterGroup     `json:"router_groups"`
+	TcpRouteMappings []models.TcpRouteMapping `json:"tcp_route_mappings"`
+
+	restored bool
+}
+
+// TakeRoutingSnapshot lists the router groups and tcp route mappings, and
+// the HTTP routes when includeHttpRoutes is set.
+func TakeRoutingSnapshot(client routing_api.Client, includeHttpRoutes bool) RoutingSnapshot {
+	var (
+		snapshot RoutingSnapshot
+		err      error
+	)
+
+	if includeHttpRoutes {
+		snapshot.Routes, err = client.Routes()
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	snapshot.RouterGroups, err = client.RouterGroups()
+	Expect(err).ToNot(HaveOccurred())
+
+	snapshot.TcpRouteMappings, err = client.TcpRouteMappings()
+	Expect(err).ToNot(HaveOccurred())
+
+	return snapshot
+}
+
+func (s RoutingSnapshot) Save(path string) {
+	encoded, err := json.MarshalIndent(s, "", "  ")
+	Expect(err).ToNot(HaveOccurred())
+
+	err = ioutil.WriteFile(path, encoded, 0644)
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// Only narrows the snapshot to the snapshotted counterparts of mappings, so
+// a spec can wipe and restore its own data without touching what other
+// specs, route emitters or parallel nodes registered meanwhile. Routes and
+// router groups are left out.
+func (s RoutingSnapshot) Only(mappings []models.TcpRouteMapping) RoutingSnapshot {
+	key := func(mapping models.TcpRouteMapping) string {
+		return fmt.Sprintf("%s:%d->%s:%d", mapping.RouterGroupGuid, mapping.ExternalPort, mapping.HostIP, mapping.HostPort)
+	}
+	wanted := map[string]bool{}
+	for _, mapping := range mappings {
+		wanted[key(mapping)] = true
+	}
+
+	var scoped RoutingSnapshot
+	for _, mapping := range s.TcpRouteMappings {
+		if wanted[key(mapping)] {
+			scoped.TcpRouteMappings = append(scoped.TcpRouteMappings, mapping)
+		}
+	}
+	return scoped
+}
+
+// Wipe deletes the snapshotted routes and tcp route mappings, simulating the
+// loss of the Routing API's data. Router groups are left in place since
+// Cloud Controller refers to them by guid.
+func (s *RoutingSnapshot) Wipe(client routing_api.Client) {
+	s.restored = false
+
+	if len(s.Routes) > 0 {
+		err := client.DeleteRoutes(s.Routes)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	if len(s.TcpRouteMappings) > 0 {
+		err := client.DeleteTcpRouteMappings(s.TcpRouteMappings)
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
+
+// Restore writes the snapshot back: router groups get their reservable ports
+// back, and routes and tcp route mappings are upserted with their TTLs. Once
+// it has succeeded, calling it again does nothing, so specs can register it
+// for cleanup as soon as they take the snapshot and still restore early.
+func (s *RoutingSnapshot) Restore(client routing_api.Client) {
+	if s.restored {
+		return
+	}
+
+	for _, routerGroup := range s.RouterGroups {
+		err := client.UpdateRouterGroup(routerGroup)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	if len(s.Routes) > 0 {
+		err := client.UpsertRoutes(s.Routes)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	if len(s.TcpRouteMappings) > 0 {
+		err := client.UpsertTcpRouteMappings(s.TcpRouteMappings)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	s.restored = true
+}
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// Teardown collects cleanup steps so that a failing step does not prevent
+// the remaining ones from running.
+type Teardown struct {
+	steps []teardownStep
+}
+
+type teardownStep struct {
+	name string
+	fn   func()
+}
+
+func NewTeardown() *Teardown {
+	return &Teardown{}
+}
+
+// Add registers a cleanup step. Steps run in reverse order of registration,
+// so resources are released in the opposite order they were created.
+func (t *Teardown) Add(name string, fn func()) {
+	t.steps = append(t.steps, teardownStep{name: name, fn: fn})
+}
+
+// Run executes every registered step, carrying on past failed assertions
+// and panics, and returns an error aggregating all failures. Registered
+// steps are cleared afterwards.
+func (t *Teardown) Run() error {
+	var failures []string
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		for _, failure := range runTeardownStep(step.fn) {
+			failures = append(failures, fmt.Sprintf("%s: %s", step.name, failure))
+		}
+	}
+	t.steps = nil
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func runTeardownStep(fn func()) []string {
+	var panics []string
+	failures := InterceptGomegaFailures(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panics = append(panics, fmt.Sprintf("%v", r))
+			}
+		}()
+		fn()
+	})
+	return append(failures, panics...)
+}
@@ -29,8 +29,46 @@ type RoutingConfig struct {
 	TcpAppDomain      string       `json:"tcp_apps_domain"`
 	LBConfigured      bool         `json:"lb_configured"`
 	TCPRouterGroup    string       `json:"tcp_router_group"`
+	TcpRouterBackend  string       `json:"tcp_router_backend"`
+
+	IncludeRouterGroupCreation bool                   `json:"include_router_group_creation"`
+	IncludeRoutingDataLoss     bool                   `json:"include_routing_data_loss"`
+	IncludeTcpMappingScale     bool                   `json:"include_tcp_mapping_scale"`
+	ScopedOAuthClients         *ScopedOAuthClients    `json:"oauth_scoped_clients"`
+	RoutingApiTLS              *RoutingApiTLSConfig   `json:"routing_api_tls"`
+	RoutingApiBackend          string                 `json:"routing_api_backend"`
+	RoutingApiRateLimitBurst   int                    `json:"routing_api_rate_limit_burst"`
+	RoutingApiMaxPayloadBytes  int                    `json:"routing_api_max_payload_bytes"`
+	RoutingApiPruneInterval    int                    `json:"routing_api_prune_interval"`
+	RoutingApiMaxTTL           int                    `json:"routing_api_max_ttl"`
+	RoutingApiEventIdleTimeout int                    `json:"routing_api_event_idle_timeout"`
+	RoutingApiMigration        *RoutingApiMigration   `json:"routing_api_migration"`
+	ShortLivedOAuthClient      *ShortLivedOAuthClient `json:"oauth_short_lived_client"`
+	DockerImages               *DockerImages          `json:"docker_images"`
+	PrebuildAssets             *PrebuildAssets        `json:"prebuild_assets"`
+	Nats                       *NatsConfig            `json:"nats"`
+	Perf                       *PerfConfig            `json:"perf"`
+	PerfThresholds             *PerfThresholds        `json:"perf_thresholds"`
+	PerfExport                 *PerfExportConfig      `json:"perf_export"`
+	RouterDebugEndpoints       []RouterDebugEndpoint  `json:"router_debug_endpoints"`
+
+	TcpMappingScaleCount       int `json:"tcp_mapping_scale_count"`
+	TcpMappingScaleTimeout     int `json:"tcp_mapping_scale_timeout"`
+	TcpFirstConnectionBudget   int `json:"tcp_first_connection_budget"`
+	TcpUnhealthyBackendTimeout int `json:"tcp_unhealthy_backend_timeout"`
 }
 
+const (
+	HaproxyTcpRouterBackend = "haproxy"
+	EnvoyTcpRouterBackend   = "envoy"
+
+	SqlRoutingApiBackend  = "sql"
+	EtcdRoutingApiBackend = "etcd"
+
+	SeedMigrationPhase   = "seed"
+	VerifyMigrationPhase = "verify"
+)
+
 type OAuthConfig struct {
 	TokenEndpoint string `json:"token_endpoint"`
 	ClientName    string `json:"client_name"`
@@ -38,6 +76,114 @@ type OAuthConfig struct {
 	Port          int    `json:"port"`
 }
 
+// ScopedOAuthClients are UAA clients holding a restricted set of routing
+// scopes, used to check the Routing API enforces them.
+type ScopedOAuthClients struct {
+	RoutesRead      *OAuthClient `json:"routes_read"`
+	RoutesWrite     *OAuthClient `json:"routes_write"`
+	NoRoutingScopes *OAuthClient `json:"no_routing_scopes"`
+}
+
+type OAuthClient struct {
+	ClientName   string `json:"client_name"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// ShortLivedOAuthClient is a UAA client whose access tokens are valid for
+// TokenValidity seconds, short enough for a spec to outlive one.
+type ShortLivedOAuthClient struct {
+	OAuthClient
+	TokenValidity int `json:"token_validity"`
+}
+
+// PerfConfig sizes the load the perf suite drives through the routers.
+// The latency specs run every one of Profiles; without any they run a
+// single steady profile of Concurrency clients for Duration seconds.
+type PerfConfig struct {
+	Concurrency int           `json:"concurrency"`
+	Duration    int           `json:"duration"`
+	Profiles    []LoadProfile `json:"profiles"`
+	WarmUp      int           `json:"warm_up"`
+
+	RegistrationMaxRate      int `json:"registration_max_rate"`
+	RegistrationStepDuration int `json:"registration_step_duration"`
+
+	PropagationSamples int `json:"propagation_samples"`
+
+	ConnectionMaxRate      int `json:"connection_max_rate"`
+	ConnectionStepDuration int `json:"connection_step_duration"`
+
+	SoakDuration      string `json:"soak_duration"`
+	SoakCheckInterval int    `json:"soak_check_interval"`
+
+	ResourceSampleInterval int `json:"resource_sample_interval"`
+
+	DirectBackendAccess bool `json:"direct_backend_access"`
+
+	IncludeRouteTableScale    bool `json:"include_route_table_scale"`
+	RouteTableSize            int  `json:"route_table_size"`
+	RouteTableRegistrationSla int  `json:"route_table_registration_sla"`
+	RouteTablePruneSla        int  `json:"route_table_prune_sla"`
+
+	IncludeTcpMappingChurn bool `json:"include_tcp_mapping_churn"`
+}
+
+// PerfThresholds are the pass/fail criteria perf scenarios are held to. Each
+// one only fails a scenario when it is set.
+type PerfThresholds struct {
+	MaxP99LatencyMs int     `json:"max_p99_latency_ms"`
+	MaxErrorRate    float64 `json:"max_error_rate"`
+	MinThroughput   float64 `json:"min_throughput"`
+}
+
+// SoakPeriod is how long the soak spec runs for, zero when it is disabled.
+// LoadConfig has already rejected a SoakDuration that does not parse.
+func (c PerfConfig) SoakPeriod() time.Duration {
+	period, _ := time.ParseDuration(c.SoakDuration)
+	return period
+}
+
+// DockerImages are published builds of the assets' Dockerfiles, pushed by
+// the docker lifecycle specs instead of building images at test time.
+type DockerImages struct {
+	Golang            string `json:"golang"`
+	TcpSampleReceiver string `json:"tcp_sample_receiver"`
+}
+
+// PrebuildAssets has the suites compile Go assets locally and push the
+// binaries with the binary buildpack, instead of staging every push with the
+// Go buildpack.
+type PrebuildAssets struct {
+	CacheDir            string `json:"cache_dir"`
+	Goarch              string `json:"goarch"`
+	BinaryBuildpackName string `json:"binary_buildpack_name"`
+}
+
+// NatsConfig points the suites at the NATS cluster gorouter subscribes to.
+type NatsConfig struct {
+	Servers  []string `json:"servers"`
+	User     string   `json:"user"`
+	Password string   `json:"password"`
+}
+
+// RoutingApiTLSConfig points the suites at a Routing API listener that
+// requires client certificates.
+type RoutingApiTLSConfig struct {
+	ApiUrl         string `json:"api_url"`
+	CACertFile     string `json:"ca_cert_file"`
+	ClientCertFile string `json:"client_cert_file"`
+	ClientKeyFile  string `json:"client_key_file"`
+}
+
+// RoutingApiMigration drives the etcd to SQL migration specs across two runs:
+// the seed run records what it wrote to SnapshotFile, the operator migrates
+// the Routing API, and the verify run compares the store against it.
+type RoutingApiMigration struct {
+	Phase        string `json:"phase"`
+	SnapshotFile string `json:"snapshot_file"`
+	RouteTTL     int    `json:"route_ttl"`
+}
+
 func loadDefaultTimeout(conf *RoutingConfig) {
 	if conf.DefaultTimeout <= 0 {
 		conf.DefaultTimeout = 120
@@ -46,12 +192,120 @@ func loadDefaultTimeout(conf *RoutingConfig) {
 	if conf.CfPushTimeout <= 0 {
 		conf.CfPushTimeout = 120
 	}
+
+	if conf.TcpMappingScaleTimeout <= 0 {
+		conf.TcpMappingScaleTimeout = 120
+	}
+
+	if conf.TcpUnhealthyBackendTimeout <= 0 {
+		conf.TcpUnhealthyBackendTimeout = 30
+	}
+
+	if conf.RoutingApiPruneInterval <= 0 {
+		conf.RoutingApiPruneInterval = 60
+	}
+
+	if conf.RoutingApiMaxTTL <= 0 {
+		conf.RoutingApiMaxTTL = 120
+	}
+
+	if conf.RoutingApiEventIdleTimeout <= 0 {
+		conf.RoutingApiEventIdleTimeout = 60
+	}
+
+	if conf.TcpFirstConnectionBudget <= 0 {
+		conf.TcpFirstConnectionBudget = 60
+	}
+}
+
+func loadDefaultPerf(conf *RoutingConfig) {
+	if conf.Perf == nil {
+		conf.Perf = &PerfConfig{}
+	}
+
+	if conf.Perf.Concurrency <= 0 {
+		conf.Perf.Concurrency = 10
+	}
+
+	if conf.Perf.Duration <= 0 {
+		conf.Perf.Duration = 60
+	}
+
+	if conf.PerfThresholds == nil {
+		conf.PerfThresholds = &PerfThresholds{}
+	}
+
+	if len(conf.Perf.Profiles) == 0 {
+		conf.Perf.Profiles = []LoadProfile{{Name: "steady"}}
+	}
+
+	for i := range conf.Perf.Profiles {
+		profile := &conf.Perf.Profiles[i]
+		if profile.Name == "" {
+			profile.Name = fmt.Sprintf("profile %d", i+1)
+		}
+		if profile.Concurrency <= 0 {
+			profile.Concurrency = conf.Perf.Concurrency
+		}
+		if profile.Duration <= 0 {
+			profile.Duration = conf.Perf.Duration
+		}
+	}
+
+	if conf.Perf.RegistrationMaxRate <= 0 {
+		conf.Perf.RegistrationMaxRate = 500
+	}
+
+	if conf.Perf.RegistrationStepDuration <= 0 {
+		conf.Perf.RegistrationStepDuration = 10
+	}
+
+	if conf.Perf.PropagationSamples <= 0 {
+		conf.Perf.PropagationSamples = 200
+	}
+
+	if conf.Perf.ConnectionMaxRate <= 0 {
+		conf.Perf.ConnectionMaxRate = 1000
+	}
+
+	if conf.Perf.ConnectionStepDuration <= 0 {
+		conf.Perf.ConnectionStepDuration = 10
+	}
+
+	if conf.Perf.SoakCheckInterval <= 0 {
+		conf.Perf.SoakCheckInterval = 300
+	}
+
+	if conf.Perf.ResourceSampleInterval <= 0 {
+		conf.Perf.ResourceSampleInterval = 5
+	}
+
+	if conf.Perf.RouteTableSize <= 0 {
+		conf.Perf.RouteTableSize = 10000
+	}
+
+	if conf.Perf.RouteTableRegistrationSla <= 0 {
+		conf.Perf.RouteTableRegistrationSla = 120
+	}
+
+	if conf.Perf.RouteTablePruneSla <= 0 {
+		conf.Perf.RouteTablePruneSla = 240
+	}
 }
+
+func loadDefaultTcpMappingScale(conf *RoutingConfig) {
+	if conf.TcpMappingScaleCount <= 0 {
+		conf.TcpMappingScaleCount = 1000
+	}
+}
+
 func LoadConfig() RoutingConfig {
 	loadedConfig := loadConfigJsonFromPath()
 
 	loadedConfig.Config = config.LoadConfig()
 	loadDefaultTimeout(&loadedConfig)
+	loadDefaultTcpMappingScale(&loadedConfig)
+	loadDefaultPerf(&loadedConfig)
 
 	if loadedConfig.OAuth == nil {
 		panic("missing configuration oauth")
@@ -73,8 +327,108 @@ func LoadConfig() RoutingConfig {
 		panic("missing configuration tcp_router_group")
 	}
 
+	if loadedConfig.TcpRouterBackend == "" {
+		loadedConfig.TcpRouterBackend = HaproxyTcpRouterBackend
+	}
+
+	switch loadedConfig.TcpRouterBackend {
+	case HaproxyTcpRouterBackend, EnvoyTcpRouterBackend:
+	default:
+		panic(fmt.Sprintf("invalid configuration tcp_router_backend %q", loadedConfig.TcpRouterBackend))
+	}
+
 	loadedConfig.RoutingApiUrl = fmt.Sprintf("https://%s", loadedConfig.ApiEndpoint)
 
+	switch loadedConfig.RoutingApiBackend {
+	case "", SqlRoutingApiBackend, EtcdRoutingApiBackend:
+	default:
+		panic(fmt.Sprintf("invalid configuration routing_api_backend %q", loadedConfig.RoutingApiBackend))
+	}
+
+	if loadedConfig.RoutingApiTLS != nil && loadedConfig.RoutingApiTLS.ApiUrl == "" {
+		panic("missing configuration routing_api_tls.api_url")
+	}
+
+	if images := loadedConfig.DockerImages; images != nil && (images.Golang == "" || images.TcpSampleReceiver == "") {
+		panic("missing configuration docker_images.golang or docker_images.tcp_sample_receiver")
+	}
+
+	if prebuild := loadedConfig.PrebuildAssets; prebuild != nil {
+		if prebuild.Goarch == "" {
+			prebuild.Goarch = "amd64"
+		}
+		if prebuild.BinaryBuildpackName == "" {
+			prebuild.BinaryBuildpackName = "binary_buildpack"
+		}
+	}
+
+	if natsConfig := loadedConfig.Nats; natsConfig != nil && len(natsConfig.Servers) == 0 {
+		panic("missing configuration nats.servers")
+	}
+
+	for _, profile := range loadedConfig.Perf.Profiles {
+		if profile.SpikeConcurrency > 0 && (profile.SpikeInterval <= 0 || profile.SpikeDuration <= 0 || profile.SpikeDuration > profile.SpikeInterval) {
+			panic(fmt.Sprintf("invalid configuration perf.profiles %q: spike_duration must be positive and at most spike_interval", profile.Name))
+		}
+	}
+
+	if export := loadedConfig.PerfExport; export != nil {
+		if influx := export.InfluxDB; influx != nil {
+			if influx.Url == "" {
+				panic("missing configuration perf_export.influxdb.url")
+			}
+			if influx.Database == "" && (influx.Token == "" || influx.Org == "" || influx.Bucket == "") {
+				panic("missing configuration perf_export.influxdb.database, or token, org and bucket")
+			}
+		}
+
+		if gateway := export.Pushgateway; gateway != nil {
+			if gateway.Url == "" {
+				panic("missing configuration perf_export.pushgateway.url")
+			}
+			if gateway.Job == "" {
+				gateway.Job = "routing_perf"
+			}
+		}
+	}
+
+	for i := range loadedConfig.RouterDebugEndpoints {
+		endpoint := &loadedConfig.RouterDebugEndpoints[i]
+		if endpoint.Name == "" || endpoint.Url == "" {
+			panic("missing configuration router_debug_endpoints name or url")
+		}
+		if endpoint.Format == "" {
+			endpoint.Format = PrometheusDebugFormat
+		}
+		if endpoint.Format != PrometheusDebugFormat && endpoint.Format != VarzDebugFormat {
+			panic(fmt.Sprintf("invalid configuration router_debug_endpoints format %q", endpoint.Format))
+		}
+	}
+
+	if soak := loadedConfig.Perf.SoakDuration; soak != "" {
+		if period, err := time.ParseDuration(soak); err != nil || period <= 0 {
+			panic(fmt.Sprintf("invalid configuration perf.soak_duration %q", soak))
+		}
+	}
+
+	if client := loadedConfig.ShortLivedOAuthClient; client != nil && client.TokenValidity <= 30 {
+		panic("invalid configuration oauth_short_lived_client.token_validity: must be more than 30 seconds")
+	}
+
+	if migration := loadedConfig.RoutingApiMigration; migration != nil {
+		if migration.Phase != SeedMigrationPhase && migration.Phase != VerifyMigrationPhase {
+			panic(fmt.Sprintf("invalid configuration routing_api_migration.phase %q", migration.Phase))
+		}
+
+		if migration.SnapshotFile == "" {
+			panic("missing configuration routing_api_migration.snapshot_file")
+		}
+
+		if migration.RouteTTL <= 0 {
+			migration.RouteTTL = 3600
+		}
+	}
+
 	return loadedConfig
 }
 
@@ -88,14 +442,23 @@ func ValidateRouterGroupName(context cfworkflow_helpers.UserContext, tcpRouterGr
 }
 
 func NewUaaClient(routerApiConfig RoutingConfig, logger lager.Logger) uaaclient.Client {
+	client := OAuthClient{
+		ClientName:   routerApiConfig.OAuth.ClientName,
+		ClientSecret: routerApiConfig.OAuth.ClientSecret,
+	}
+	return NewUaaClientFor(routerApiConfig, client, logger)
+}
+
+// NewUaaClientFor fetches tokens for client from the configured token endpoint.
+func NewUaaClientFor(routerApiConfig RoutingConfig, client OAuthClient, logger lager.Logger) uaaclient.Client {
 
 	tokenURL := fmt.Sprintf("%s:%d", routerApiConfig.OAuth.TokenEndpoint, routerApiConfig.OAuth.Port)
 
 	cfg := &uaaconfig.Config{
 		UaaEndpoint:           tokenURL,
 		SkipVerification:      routerApiConfig.SkipSSLValidation,
-		ClientName:            routerApiConfig.OAuth.ClientName,
-		ClientSecret:          routerApiConfig.OAuth.ClientSecret,
+		ClientName:            client.ClientName,
+		ClientSecret:          client.ClientSecret,
 		MaxNumberOfRetries:    3,
 		RetryInterval:         500 * time.Millisecond,
 		ExpirationBufferInSec: 30,
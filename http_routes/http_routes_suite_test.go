@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
 
+	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
 
 	"testing"
@@ -34,7 +35,10 @@ const (
 	DEFAULT_MEMORY_LIMIT     = "256M"
 )
 
-var routerApiConfig helpers.RoutingConfig
+var (
+	routerApiConfig helpers.RoutingConfig
+	capabilities    helpers.RoutingApiCapabilities
+)
 
 func TestRouting(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -43,12 +47,20 @@ func TestRouting(t *testing.T) {
 
 	BeforeSuite(func() {
 		Expect(routerApiConfig.OAuth.ClientSecret).ToNot(Equal(""), "Must provide a client secret for the routing suite")
+
+		if routerApiConfig.IncludeHttpRoutes {
+			uaaClient := helpers.NewUaaClient(routerApiConfig, lagertest.NewTestLogger("test"))
+			token, err := uaaClient.FetchToken(true)
+			Expect(err).ToNot(HaveOccurred())
+			capabilities = helpers.DiscoverRoutingApiCapabilities(routerApiConfig, token.AccessToken)
+		}
 	})
 
 	BeforeEach(func() {
 		if !routerApiConfig.IncludeHttpRoutes {
 			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
 		}
+		capabilities.Require(helpers.HttpRoutesCapability)
 	})
 
 	RunSpecs(t, "HTTP Routes Suite")
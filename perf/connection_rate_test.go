@@ -0,0 +1,84 @@
+package perf_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const CONNECTION_RATE_STEPS = 10
+
+// This spec opens short-lived connections through one tcp route at a rising
+// rate, each carrying a single message, and reports the highest rate the tcp
+// routers accepted without a failed connection. Regressions in haproxy's
+// accept and connection tuning show up as a lower rate.
+var _ = Describe("TCP connection setup rate", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("reports the connection rate the tcp routers sustain", func() {
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpDropletReceiver, "tcp-droplet-receiver --serverId=perf", TCP_BACKEND_PORT, teardown)
+		var addresses []string
+		for _, routerAddr := range routingConfig.Addresses {
+			addresses = append(addresses, fmt.Sprintf("%s:%d", routerAddr, externalPort))
+		}
+
+		maxRate := routingConfig.Perf.ConnectionMaxRate
+		stepRate := maxRate / CONNECTION_RATE_STEPS
+		if stepRate < 1 {
+			stepRate = 1
+		}
+		stepDuration := time.Duration(routingConfig.Perf.ConnectionStepDuration) * time.Second
+
+		sustainedRate := 0
+		for rate := stepRate; rate <= maxRate; rate += stepRate {
+			result := driveRate(rate, stepDuration, func(i int) error {
+				return shortLivedConnection(addresses[i%len(addresses)])
+			})
+			report(fmt.Sprintf("tcp connections at %d/s", rate), result)
+
+			if result.Errors > 0 || result.Throughput() < 0.9*float64(rate) {
+				break
+			}
+			sustainedRate = rate
+		}
+
+		fmt.Fprintf(GinkgoWriter, "\ntcp connections fail above %d/s (max tried %d/s)\n", sustainedRate, maxRate)
+		Expect(sustainedRate).To(BeNumerically(">", 0), "no rate was sustained")
+	})
+})
+
+// shortLivedConnection dials address, exchanges one message and closes the
+// connection again.
+func shortLivedConnection(address string) error {
+	request := loadgen.TCPConnect(address, DEFAULT_CONNECT_TIMEOUT, DEFAULT_RW_TIMEOUT)
+	return request(context.Background())
+}
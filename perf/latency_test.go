@@ -0,0 +1,140 @@
+package perf_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	TCP_BACKEND_PORT  = 3333
+	HTTP_BACKEND_PORT = 8080
+)
+
+// These specs put request load shaped by each configured load profile on the
+// routers in front of a single app instance and report latency percentiles
+// and the error rate per profile. They fail only when the perf thresholds in
+// the config are exceeded.
+var _ = Describe("Latency under load", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+		workers  int
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+		workers = 0
+		for _, profile := range routingConfig.Perf.Profiles {
+			if profile.Workers() > workers {
+				workers = profile.Workers()
+			}
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("through gorouter stays within the configured thresholds", func() {
+		helpers.PushGoAppNoStart(routingConfig, appName, assets.NewAssets().TcpSampleGolang, routingConfig.AppsDomain, CF_PUSH_TIMEOUT, "256M", "", "-s", "cflinuxfs3")
+		routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+		appUrl := fmt.Sprintf("%s%s.%s/", routingConfig.Protocol(), appName, routingConfig.AppsDomain)
+		client := newHttpClient(workers)
+		Eventually(func() error {
+			return httpRequest(client, appUrl)
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Succeed())
+
+		for _, profile := range routingConfig.Perf.Profiles {
+			scenario := fmt.Sprintf("gorouter, %s profile", profile.Name)
+			result := driveProfile(profile, func(int) loadgen.Request {
+				return loadgen.HTTPGet(client, appUrl)
+			})
+			report(scenario, result)
+			expectWithinThresholds(scenario, result)
+		}
+	})
+
+	It("through the tcp router stays within the configured thresholds", func() {
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpDropletReceiver, "tcp-droplet-receiver --serverId=perf", TCP_BACKEND_PORT, teardown)
+
+		for _, profile := range routingConfig.Perf.Profiles {
+			scenario := fmt.Sprintf("tcp router, %s profile", profile.Name)
+			result := driveProfile(profile, func(worker int) loadgen.Request {
+				return tcpEcho(routingConfig.Addresses[worker%len(routingConfig.Addresses)], externalPort)
+			})
+			report(scenario, result)
+			expectWithinThresholds(scenario, result)
+		}
+	})
+})
+
+func newHttpClient(concurrency int) *http.Client {
+	return &http.Client{
+		Timeout: DEFAULT_RW_TIMEOUT + DEFAULT_CONNECT_TIMEOUT,
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: concurrency,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: routingConfig.SkipSSLValidation},
+		},
+	}
+}
+
+func httpRequest(client *http.Client, url string) error {
+	return loadgen.HTTPGet(client, url)(context.Background())
+}
+
+// pushTcpBackend starts asset behind a new tcp route to backendPort and
+// returns the route's external port once every router serves it. An empty
+// command keeps the buildpack's start command.
+func pushTcpBackend(appName, asset, command string, backendPort uint16, teardown *helpers.Teardown) uint16 {
+	spaceName := environment.RegularUserContext().Space
+	externalPort := routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+	teardown.Add(fmt.Sprintf("delete tcp route %d", externalPort), func() {
+		routing_helpers.DeleteTcpRoute(domainName, fmt.Sprintf("%d", externalPort), DEFAULT_TIMEOUT)
+	})
+
+	// Uses --no-route flag so there is no HTTP route
+	helpers.PushGoAppNoStart(routingConfig, appName, asset, "", CF_PUSH_TIMEOUT, "256M", command, "--no-route", "-s", "cflinuxfs3")
+	routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+	routing_helpers.UpdatePorts(appName, []uint16{backendPort}, DEFAULT_TIMEOUT)
+	routing_helpers.CreateRouteMapping(appName, "", externalPort, backendPort, DEFAULT_TIMEOUT)
+	routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, routerAddr := range routingConfig.Addresses {
+		request := tcpEcho(routerAddr, externalPort)
+		Eventually(func() error {
+			return request(ctx)
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Succeed())
+	}
+	return externalPort
+}
+
+// tcpEcho holds a connection to the echoing backend behind externalPort on
+// routerAddr.
+func tcpEcho(routerAddr string, externalPort uint16) loadgen.Request {
+	address := fmt.Sprintf("%s:%d", routerAddr, externalPort)
+	return loadgen.TCPEcho(address, DEFAULT_CONNECT_TIMEOUT, DEFAULT_RW_TIMEOUT)
+}
@@ -0,0 +1,106 @@
+package perf_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// driveLoad runs concurrency workers back-to-back for duration. Each worker
+// is built by newWorker before any of them start, so it can hold on to its
+// own connection.
+func driveLoad(concurrency int, duration time.Duration, newWorker func(worker int) loadgen.Request) loadgen.Result {
+	return loadgen.Pool{
+		Workers:   concurrency,
+		Duration:  duration,
+		NewWorker: newWorker,
+	}.Run(context.Background())
+}
+
+// driveProfile runs the most workers profile ever needs, after the configured
+// warm-up, but lets worker i send requests only while the profile calls for
+// more than i clients.
+func driveProfile(profile helpers.LoadProfile, newWorker func(worker int) loadgen.Request) loadgen.Result {
+	return loadgen.Pool{
+		Workers:   profile.Workers(),
+		Duration:  time.Duration(profile.Duration) * time.Second,
+		WarmUp:    time.Duration(routingConfig.Perf.WarmUp) * time.Second,
+		NewWorker: newWorker,
+		Active: func(worker int, elapsed time.Duration) bool {
+			return worker < profile.ConcurrencyAt(elapsed)
+		},
+	}.Run(context.Background())
+}
+
+// driveRate starts rate requests per second for duration, without waiting
+// for earlier ones to finish, so a slow server shows up as latency rather than
+// as a lower request rate. Each call gets the sequence number of its request.
+func driveRate(rate int, duration time.Duration, request func(i int) error) loadgen.Result {
+	return loadgen.RunRate(context.Background(), rate, duration, 0, func(_ context.Context, i int) error {
+		return request(i)
+	})
+}
+
+// resourceReadings are how report prints the metrics a ResourceSampler
+// collects.
+var resourceReadings = []struct {
+	metric, format string
+	scale          float64
+}{
+	{"cpu_percent", "cpu %.1f%%", 1},
+	{"memory_bytes", "memory %.1f MiB", 1 << 20},
+	{"goroutines", "goroutines %.0f", 1},
+	{"open_fds", "open fds %.0f", 1},
+}
+
+// report logs result, along with the peak router resource usage while it was
+// measured, and keeps it for the result files written at the end of the
+// suite.
+func report(scenario string, result loadgen.Result) {
+	recorded := recordResult(scenario, result)
+	fmt.Fprintf(GinkgoWriter, "\n%s: %d requests in %s (%.1f/s), error rate %.4f, p50 %s, p95 %s, p99 %s, max %s\n",
+		scenario, result.Requests, result.Elapsed, result.Throughput(), result.ErrorRate(),
+		result.Percentile(0.50), result.Percentile(0.95), result.Percentile(0.99), result.Latencies.Max())
+
+	var endpoints []string
+	for name := range recorded.PeakResources {
+		endpoints = append(endpoints, name)
+	}
+	sort.Strings(endpoints)
+	for _, name := range endpoints {
+		peaks := recorded.PeakResources[name]
+		var readings []string
+		for _, reading := range resourceReadings {
+			if value, ok := peaks[reading.metric]; ok {
+				readings = append(readings, fmt.Sprintf(reading.format, value/reading.scale))
+			}
+		}
+		fmt.Fprintf(GinkgoWriter, "  %s peaks: %s\n", name, strings.Join(readings, ", "))
+	}
+}
+
+// expectWithinThresholds fails the spec for each of the perf_thresholds the
+// config sets that the scenario missed.
+func expectWithinThresholds(scenario string, result loadgen.Result) {
+	Expect(result.Requests).To(BeNumerically(">", 0), "%s sent no requests", scenario)
+
+	thresholds := routingConfig.PerfThresholds
+	if thresholds.MaxP99LatencyMs > 0 {
+		maxP99 := time.Duration(thresholds.MaxP99LatencyMs) * time.Millisecond
+		Expect(result.Percentile(0.99)).To(BeNumerically("<=", maxP99), "%s p99 latency", scenario)
+	}
+	if thresholds.MaxErrorRate > 0 {
+		Expect(result.ErrorRate()).To(BeNumerically("<=", thresholds.MaxErrorRate), "%s error rate", scenario)
+	}
+	if thresholds.MinThroughput > 0 {
+		Expect(result.Throughput()).To(BeNumerically(">=", thresholds.MinThroughput), "%s throughput", scenario)
+	}
+}
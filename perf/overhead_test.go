@@ -0,0 +1,102 @@
+package perf_test
+
+import (
+	"fmt"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+// This spec sends the same HTTP load to one app instance directly at its
+// backend address, through gorouter and through the tcp routers, and reports
+// how much latency and throughput each routing tier costs. The backend
+// address is only reachable from inside the deployment's network, so it needs
+// Perf.DirectBackendAccess.
+var _ = Describe("Router overhead", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+		if !routingConfig.Perf.DirectBackendAccess {
+			Skip("Skipping this test because Config.Perf.DirectBackendAccess is set to `false`.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("reports the latency and throughput the routers add", func() {
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpSampleGolang, "", HTTP_BACKEND_PORT, teardown)
+		mappings := tcpRouteMappingsForPort(externalPort)
+		Expect(mappings).NotTo(BeEmpty())
+		backend := mappings[0]
+
+		Expect(cf.Cf("map-route", appName, routingConfig.AppsDomain, "--hostname", appName).Wait(DEFAULT_TIMEOUT)).To(Exit(0))
+		teardown.Add("delete route "+appName, func() {
+			Expect(cf.Cf("delete-route", routingConfig.AppsDomain, "--hostname", appName, "-f").Wait(DEFAULT_TIMEOUT)).To(Exit(0))
+		})
+
+		paths := []struct{ scenario, url string }{
+			{"direct to backend", fmt.Sprintf("http://%s:%d/", backend.HostIP, backend.HostPort)},
+			{"through gorouter", fmt.Sprintf("%s%s.%s/", routingConfig.Protocol(), appName, routingConfig.AppsDomain)},
+			{"through the tcp router", fmt.Sprintf("http://%s:%d/", routingConfig.Addresses[0], externalPort)},
+		}
+
+		profile := helpers.LoadProfile{
+			Name:        "overhead",
+			Duration:    routingConfig.Perf.Duration,
+			Concurrency: routingConfig.Perf.Concurrency,
+		}
+		var results []loadgen.Result
+		for _, path := range paths {
+			client := newHttpClient(profile.Concurrency)
+			url := path.url
+			Eventually(func() error {
+				return httpRequest(client, url)
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Succeed())
+
+			result := driveProfile(profile, func(int) loadgen.Request {
+				return loadgen.HTTPGet(client, url)
+			})
+			report("overhead "+path.scenario, result)
+			results = append(results, result)
+		}
+
+		direct := results[0]
+		Expect(direct.Requests).To(BeNumerically(">", 0), "direct requests")
+		for i, path := range paths[1:] {
+			routed := results[i+1]
+			fmt.Fprintf(GinkgoWriter, "\n%s adds p50 %s, p99 %s and costs %.1f%% of throughput\n",
+				path.scenario,
+				addedLatency(routed, direct, 0.50), addedLatency(routed, direct, 0.99),
+				100*(1-routed.Throughput()/direct.Throughput()))
+			expectWithinThresholds("overhead "+path.scenario, routed)
+		}
+	})
+})
+
+func addedLatency(routed, direct loadgen.Result, p float64) time.Duration {
+	return routed.Percentile(p) - direct.Percentile(p)
+}
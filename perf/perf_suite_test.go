@@ -0,0 +1,123 @@
+package perf_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
+	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+	cfworkflow_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+func TestPerf(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	routingConfig = helpers.LoadConfig()
+
+	if routingConfig.DefaultTimeout > 0 {
+		DEFAULT_TIMEOUT = time.Duration(routingConfig.DefaultTimeout) * time.Second
+	}
+
+	if routingConfig.CfPushTimeout > 0 {
+		CF_PUSH_TIMEOUT = time.Duration(routingConfig.CfPushTimeout) * time.Second
+	}
+
+	componentName := "Perf"
+
+	rs := []Reporter{}
+
+	if routingConfig.ArtifactsDirectory != "" {
+		cf_helpers.EnableCFTrace(routingConfig.Config, componentName)
+		rs = append(rs, cf_helpers.NewJUnitReporter(routingConfig.Config, componentName))
+	}
+
+	RunSpecsWithDefaultAndCustomReporters(t, componentName, rs)
+}
+
+var (
+	DEFAULT_TIMEOUT          = 2 * time.Minute
+	DEFAULT_POLLING_INTERVAL = 5 * time.Second
+	CF_PUSH_TIMEOUT          = 2 * time.Minute
+	DEFAULT_CONNECT_TIMEOUT  = 5 * time.Second
+	DEFAULT_RW_TIMEOUT       = 2 * time.Second
+	domainName               string
+
+	adminContext     cfworkflow_helpers.UserContext
+	routingConfig    helpers.RoutingConfig
+	routingApiClient routing_api.Client
+	environment      *cfworkflow_helpers.ReproducibleTestSuiteSetup
+	capabilities     helpers.RoutingApiCapabilities
+	logger           lager.Logger
+	resourceSampler  *helpers.ResourceSampler
+)
+
+var _ = BeforeSuite(func() {
+	logger = lagertest.NewTestLogger("test")
+	startRun()
+	if len(routingConfig.RouterDebugEndpoints) > 0 {
+		interval := time.Duration(routingConfig.Perf.ResourceSampleInterval) * time.Second
+		resourceSampler = helpers.NewResourceSampler(routingConfig.RouterDebugEndpoints, interval, routingConfig.SkipSSLValidation)
+		resourceSampler.Start()
+	}
+	routingApiClient = helpers.NewRoutingApiClient(routingConfig)
+
+	uaaClient := helpers.NewUaaClient(routingConfig, logger)
+	token, err := uaaClient.FetchToken(true)
+	Expect(err).ToNot(HaveOccurred())
+
+	routingApiClient.SetToken(token.AccessToken)
+	_, err = routingApiClient.RouterGroups()
+	Expect(err).ToNot(HaveOccurred(), "Routing API is unavailable")
+
+	capabilities = helpers.DiscoverRoutingApiCapabilities(routingConfig, token.AccessToken)
+
+	environment = cfworkflow_helpers.NewTestSuiteSetup(routingConfig.Config)
+	adminContext = environment.AdminUserContext()
+	regUser := environment.RegularUserContext()
+	adminContext.TestSpace = regUser.TestSpace
+	adminContext.Org = regUser.Org
+	adminContext.Space = regUser.Space
+
+	environment.Setup()
+
+	helpers.ValidateRouterGroupName(adminContext, routingConfig.TCPRouterGroup)
+
+	domainName = fmt.Sprintf("%s.%s", generator.PrefixedRandomName("TCP", "DOMAIN"), routingConfig.AppsDomain)
+
+	cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
+		routing_helpers.CreateSharedDomain(domainName, routingConfig.TCPRouterGroup, DEFAULT_TIMEOUT)
+		routing_helpers.VerifySharedDomain(domainName, DEFAULT_TIMEOUT)
+	})
+})
+
+var _ = AfterSuite(func() {
+	teardown := helpers.NewTeardown()
+	teardown.Add("cleanup build artifacts", CleanupBuildArtifacts)
+	teardown.Add("teardown environment", func() {
+		environment.Teardown()
+	})
+	teardown.Add("delete shared domain "+domainName, func() {
+		cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
+			routing_helpers.DeleteSharedDomain(domainName, DEFAULT_TIMEOUT)
+		})
+	})
+	teardown.Add("export perf results", exportResults)
+	teardown.Add("write perf results", writeResults)
+	if resourceSampler != nil {
+		teardown.Add("stop sampling router resources", func() {
+			resourceSampler.Stop()
+			run.Resources = resourceSampler.Series()
+		})
+	}
+	Expect(teardown.Run()).NotTo(HaveOccurred())
+})
@@ -0,0 +1,177 @@
+package perf_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/nats"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	PROPAGATION_PORTS            = 5
+	PROPAGATION_TTL              = 120
+	PROPAGATION_POLLING_INTERVAL = 50 * time.Millisecond
+)
+
+// These specs create routes one at a time and time each from the create call
+// until every router serves it, then report the distribution over
+// Perf.PropagationSamples routes. All routes point at the same app instance,
+// whose backend address is taken from its own tcp route mapping.
+var _ = Describe("Route propagation latency", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+		backend  models.TcpRouteMapping
+		client   *http.Client
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpSampleGolang, "", HTTP_BACKEND_PORT, teardown)
+		mappings := tcpRouteMappingsForPort(externalPort)
+		Expect(mappings).NotTo(BeEmpty())
+		backend = mappings[0]
+
+		// Every poll dials afresh, so a connection opened before a route
+		// changed cannot hide the change.
+		client = newHttpClient(1)
+		client.Transport.(*http.Transport).DisableKeepAlives = true
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("reports how long new tcp route mappings take to reach the tcp routers", func() {
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).NotTo(HaveOccurred())
+
+		var pool []models.TcpRouteMapping
+		for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, PROPAGATION_PORTS) {
+			pool = append(pool, models.NewTcpRouteMapping(routerGroup.Guid, port, backend.HostIP, backend.HostPort, PROPAGATION_TTL))
+		}
+		teardown.Add("delete propagation mappings", func() {
+			Expect(routingApiClient.DeleteTcpRouteMappings(pool)).To(Succeed())
+		})
+
+		recorder := loadgen.NewRecorder()
+		for i := 0; i < routingConfig.Perf.PropagationSamples; i++ {
+			mapping := pool[i%len(pool)]
+			var urls []string
+			for _, routerAddr := range routingConfig.Addresses {
+				urls = append(urls, fmt.Sprintf("http://%s:%d/", routerAddr, mapping.ExternalPort))
+			}
+
+			recorder.Time(func() error {
+				err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+				if err != nil {
+					return err
+				}
+				return awaitServed(client, urls)
+			})
+
+			// The port is reused a few samples later, so it must be gone
+			// from every router before then.
+			Expect(routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})).To(Succeed())
+			for _, url := range urls {
+				u := url
+				Eventually(func() error {
+					return httpRequest(client, u)
+				}, DEFAULT_TIMEOUT, PROPAGATION_POLLING_INTERVAL).ShouldNot(Succeed())
+			}
+		}
+
+		result := recorder.Finish()
+		report("tcp route mapping propagation", result)
+		Expect(result.Errors).To(BeZero(), "tcp route mappings were not served within %s", DEFAULT_TIMEOUT)
+	})
+
+	It("reports how long new NATS routes take to reach gorouter", func() {
+		if routingConfig.Nats == nil {
+			Skip("Skipping this test because Config.Nats is not set.")
+		}
+
+		registrar := nats.NewRegistrar(routingConfig.Nats)
+		defer registrar.Close()
+
+		prefix := helpers.RandomName()
+		recorder := loadgen.NewRecorder()
+		for i := 0; i < routingConfig.Perf.PropagationSamples; i++ {
+			host := fmt.Sprintf("%s-%d.%s", prefix, i, routingConfig.AppsDomain)
+			route := nats.Route{
+				Host: backend.HostIP,
+				Port: backend.HostPort,
+				Uris: []string{host},
+			}
+			url := fmt.Sprintf("%s%s/", routingConfig.Protocol(), host)
+
+			recorder.Time(func() error {
+				err := registrar.Register(route)
+				if err != nil {
+					return err
+				}
+				return awaitServed(client, []string{url})
+			})
+			Expect(registrar.Unregister(route)).To(Succeed())
+		}
+
+		result := recorder.Finish()
+		report("NATS route propagation", result)
+		Expect(result.Errors).To(BeZero(), "NATS routes were not served within %s", DEFAULT_TIMEOUT)
+	})
+})
+
+// awaitServed polls each url in turn until it answers with 200 and returns
+// the last error once DEFAULT_TIMEOUT passes.
+func awaitServed(client *http.Client, urls []string) error {
+	deadline := time.Now().Add(DEFAULT_TIMEOUT)
+	for _, url := range urls {
+		for {
+			err := httpRequest(client, url)
+			if err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				return err
+			}
+			time.Sleep(PROPAGATION_POLLING_INTERVAL)
+		}
+	}
+	return nil
+}
+
+func tcpRouteMappingsForPort(externalPort uint16) []models.TcpRouteMapping {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	mappings, err := helpers.NewContextClient(routingApiClient).TcpRouteMappings(ctx)
+	Expect(err).ToNot(HaveOccurred())
+
+	var result []models.TcpRouteMapping
+	for _, mapping := range mappings {
+		if mapping.ExternalPort == externalPort {
+			result = append(result, mapping)
+		}
+	}
+	return result
+}
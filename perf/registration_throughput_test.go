@@ -0,0 +1,105 @@
+package perf_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	REGISTRATION_STEPS       = 10
+	REGISTRATION_TTL         = 60
+	REGISTRATION_POOL_SIZE   = 100
+	REGISTRATION_KNEE_FACTOR = 3
+)
+
+// These specs upsert routes at a rising rate, the way route emitters refresh
+// them, until the Routing API's latency degrades, and report the highest rate
+// it sustained. Upserts cycle through a fixed pool of routes so the store
+// does not grow with the rate.
+var _ = Describe("Route registration throughput", func() {
+	var stepDuration time.Duration
+
+	BeforeEach(func() {
+		stepDuration = time.Duration(routingConfig.Perf.RegistrationStepDuration) * time.Second
+	})
+
+	It("reports the HTTP route upsert rate the Routing API sustains", func() {
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+
+		prefix := helpers.RandomName()
+		var pool []models.Route
+		for i := 0; i < REGISTRATION_POOL_SIZE; i++ {
+			pool = append(pool, models.NewRoute(fmt.Sprintf("%s-%d.perf.example.com", prefix, i), 8080, "10.255.0.1", "", "", REGISTRATION_TTL))
+		}
+		defer func() {
+			Expect(routingApiClient.DeleteRoutes(pool)).To(Succeed())
+		}()
+
+		knee := findRegistrationKnee("http route", stepDuration, func(i int) error {
+			return routingApiClient.UpsertRoutes([]models.Route{pool[i%len(pool)]})
+		})
+		Expect(knee).To(BeNumerically(">", 0), "no rate was sustained")
+	})
+
+	It("reports the tcp route mapping upsert rate the Routing API sustains", func() {
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).NotTo(HaveOccurred())
+		externalPort := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+
+		var pool []models.TcpRouteMapping
+		for i := 0; i < REGISTRATION_POOL_SIZE; i++ {
+			pool = append(pool, models.NewTcpRouteMapping(routerGroup.Guid, externalPort, "10.255.0.1", uint16(10000+i), REGISTRATION_TTL))
+		}
+		defer func() {
+			Expect(routingApiClient.DeleteTcpRouteMappings(pool)).To(Succeed())
+		}()
+
+		knee := findRegistrationKnee("tcp route mapping", stepDuration, func(i int) error {
+			return routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{pool[i%len(pool)]})
+		})
+		Expect(knee).To(BeNumerically(">", 0), "no rate was sustained")
+	})
+})
+
+// findRegistrationKnee raises the upsert rate in equal steps up to the
+// configured maximum. The first step's p99 is the baseline; a step is
+// sustained when it achieves 90% of its rate without errors and with a p99
+// under REGISTRATION_KNEE_FACTOR times the baseline. It returns the last
+// sustained rate, stopping at the first step that is not.
+func findRegistrationKnee(kind string, stepDuration time.Duration, upsert func(i int) error) int {
+	maxRate := routingConfig.Perf.RegistrationMaxRate
+	stepRate := maxRate / REGISTRATION_STEPS
+	if stepRate < 1 {
+		stepRate = 1
+	}
+
+	knee := 0
+	var baseline time.Duration
+	for rate := stepRate; rate <= maxRate; rate += stepRate {
+		result := driveRate(rate, stepDuration, upsert)
+		report(fmt.Sprintf("%s upserts at %d/s", kind, rate), result)
+
+		if baseline == 0 {
+			baseline = result.Percentile(0.99)
+		}
+		sustained := result.Errors == 0 &&
+			result.Throughput() >= 0.9*float64(rate) &&
+			result.Percentile(0.99) <= REGISTRATION_KNEE_FACTOR*baseline
+		if !sustained {
+			break
+		}
+		knee = rate
+	}
+
+	fmt.Fprintf(GinkgoWriter, "\n%s upserts degrade above %d/s (max tried %d/s)\n", kind, knee, maxRate)
+	return knee
+}
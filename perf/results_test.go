@@ -0,0 +1,183 @@
+package perf_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// perfRun is everything one run of the suite measured, in the form it is
+// written to the artifacts directory.
+type perfRun struct {
+	Environment perfEnvironment                     `json:"environment"`
+	Results     []scenarioResult                    `json:"results"`
+	Resources   map[string][]helpers.ResourceSample `json:"resources,omitempty"`
+}
+
+type perfEnvironment struct {
+	StartedAt         time.Time `json:"started_at"`
+	Api               string    `json:"api"`
+	AppsDomain        string    `json:"apps_domain"`
+	Addresses         []string  `json:"addresses"`
+	TcpRouterGroup    string    `json:"tcp_router_group"`
+	TcpRouterBackend  string    `json:"tcp_router_backend"`
+	RoutingApiBackend string    `json:"routing_api_backend"`
+	GoVersion         string    `json:"go_version"`
+}
+
+type scenarioResult struct {
+	Scenario       string    `json:"scenario"`
+	FinishedAt     time.Time `json:"finished_at"`
+	Requests       int       `json:"requests"`
+	Errors         int       `json:"errors"`
+	ErrorRate      float64   `json:"error_rate"`
+	Throughput     float64   `json:"throughput_per_second"`
+	ElapsedSeconds float64   `json:"elapsed_seconds"`
+	P50Ms          float64   `json:"p50_ms"`
+	P90Ms          float64   `json:"p90_ms"`
+	P95Ms          float64   `json:"p95_ms"`
+	P99Ms          float64   `json:"p99_ms"`
+	P999Ms         float64   `json:"p999_ms"`
+	MaxMs          float64   `json:"max_ms"`
+
+	// PeakResources holds the highest router resource usage sampled while
+	// the scenario ran, by debug endpoint.
+	PeakResources map[string]map[string]float64 `json:"peak_resources,omitempty"`
+}
+
+var run perfRun
+
+func startRun() {
+	run = perfRun{
+		Environment: perfEnvironment{
+			StartedAt:         time.Now().UTC(),
+			Api:               routingConfig.ApiEndpoint,
+			AppsDomain:        routingConfig.AppsDomain,
+			Addresses:         routingConfig.Addresses,
+			TcpRouterGroup:    routingConfig.TCPRouterGroup,
+			TcpRouterBackend:  routingConfig.TcpRouterBackend,
+			RoutingApiBackend: routingConfig.RoutingApiBackend,
+			GoVersion:         runtime.Version(),
+		},
+	}
+}
+
+func recordResult(scenario string, result loadgen.Result) scenarioResult {
+	finishedAt := time.Now().UTC()
+	var peaks map[string]map[string]float64
+	if resourceSampler != nil {
+		peaks = resourceSampler.Peaks(finishedAt.Add(-result.Elapsed), finishedAt)
+	}
+
+	r := scenarioResult{
+		Scenario:       scenario,
+		FinishedAt:     finishedAt,
+		Requests:       result.Requests,
+		Errors:         result.Errors,
+		ErrorRate:      result.ErrorRate(),
+		Throughput:     result.Throughput(),
+		ElapsedSeconds: result.Elapsed.Seconds(),
+		P50Ms:          milliseconds(result.Percentile(0.50)),
+		P90Ms:          milliseconds(result.Percentile(0.90)),
+		P95Ms:          milliseconds(result.Percentile(0.95)),
+		P99Ms:          milliseconds(result.Percentile(0.99)),
+		P999Ms:         milliseconds(result.Percentile(0.999)),
+		MaxMs:          milliseconds(result.Latencies.Max()),
+		PeakResources:  peaks,
+	}
+	run.Results = append(run.Results, r)
+	return r
+}
+
+// writeResults saves the run as perf_results_<node>.json and, one row per
+// scenario with the environment repeated on every row, as
+// perf_results_<node>.csv.
+func writeResults() {
+	if routingConfig.ArtifactsDirectory == "" || len(run.Results) == 0 {
+		return
+	}
+	base := filepath.Join(routingConfig.ArtifactsDirectory, fmt.Sprintf("perf_results_%d", GinkgoParallelNode()))
+
+	encoded, err := json.MarshalIndent(run, "", "  ")
+	Expect(err).ToNot(HaveOccurred())
+	err = ioutil.WriteFile(base+".json", encoded, 0644)
+	Expect(err).ToNot(HaveOccurred())
+
+	file, err := os.Create(base + ".csv")
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	env := run.Environment
+	w := csv.NewWriter(file)
+	w.Write([]string{
+		"started_at", "api", "tcp_router_backend", "routing_api_backend", "go_version",
+		"scenario", "finished_at", "requests", "errors", "error_rate", "throughput_per_second", "elapsed_seconds",
+		"p50_ms", "p90_ms", "p95_ms", "p99_ms", "p999_ms", "max_ms",
+	})
+	for _, r := range run.Results {
+		w.Write([]string{
+			env.StartedAt.Format(time.RFC3339), env.Api, env.TcpRouterBackend, env.RoutingApiBackend, env.GoVersion,
+			r.Scenario, r.FinishedAt.Format(time.RFC3339), strconv.Itoa(r.Requests), strconv.Itoa(r.Errors),
+			formatFloat(r.ErrorRate), formatFloat(r.Throughput), formatFloat(r.ElapsedSeconds),
+			formatFloat(r.P50Ms), formatFloat(r.P90Ms), formatFloat(r.P95Ms), formatFloat(r.P99Ms), formatFloat(r.P999Ms), formatFloat(r.MaxMs),
+		})
+	}
+	w.Flush()
+	Expect(w.Error()).ToNot(HaveOccurred())
+}
+
+// exportResults pushes the run to the time series databases in
+// Config.PerfExport, labelled with the environment it ran against.
+func exportResults() {
+	if routingConfig.PerfExport == nil || len(run.Results) == 0 {
+		return
+	}
+
+	env := run.Environment
+	labels := map[string]string{
+		"api":                 env.Api,
+		"tcp_router_group":    env.TcpRouterGroup,
+		"tcp_router_backend":  env.TcpRouterBackend,
+		"routing_api_backend": env.RoutingApiBackend,
+	}
+	var samples []helpers.PerfSample
+	for _, r := range run.Results {
+		samples = append(samples, helpers.PerfSample{
+			Scenario: r.Scenario,
+			Time:     r.FinishedAt,
+			Values: map[string]float64{
+				"requests":              float64(r.Requests),
+				"errors":                float64(r.Errors),
+				"error_rate":            r.ErrorRate,
+				"throughput_per_second": r.Throughput,
+				"p50_ms":                r.P50Ms,
+				"p90_ms":                r.P90Ms,
+				"p95_ms":                r.P95Ms,
+				"p99_ms":                r.P99Ms,
+				"p999_ms":               r.P999Ms,
+				"max_ms":                r.MaxMs,
+			},
+		})
+	}
+	Expect(helpers.ExportPerfSamples(routingConfig.PerfExport, labels, samples)).To(Succeed())
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
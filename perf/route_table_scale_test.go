@@ -0,0 +1,186 @@
+package perf_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/nats"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	ROUTE_TABLE_BASELINE_SIZE    = 10
+	ROUTE_TABLE_SAMPLES          = 100
+	ROUTE_TABLE_REFRESH_INTERVAL = 20 * time.Second
+	ROUTE_TABLE_POLLING_INTERVAL = time.Second
+)
+
+// This spec grows gorouter's routing table from a handful of routes to
+// Perf.RouteTableSize routes registered over NATS, all leading to one app
+// instance. It compares lookup latency under load at both sizes, and checks
+// that the full table is registered and, once no longer refreshed, pruned
+// within the configured SLAs. Router memory is in the report when
+// router_debug_endpoints are set.
+var _ = Describe("Route table scale", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+		if !routingConfig.Perf.IncludeRouteTableScale {
+			Skip("Skipping this test because Config.Perf.IncludeRouteTableScale is set to `false`.")
+		}
+		if routingConfig.Nats == nil {
+			Skip("Skipping this test because Config.Nats is not set.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("reports lookup latency at scale and registers and prunes routes within the SLAs", func() {
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpSampleGolang, "", HTTP_BACKEND_PORT, teardown)
+		mappings := tcpRouteMappingsForPort(externalPort)
+		Expect(mappings).NotTo(BeEmpty())
+		backend := mappings[0]
+
+		registrar := nats.NewRegistrar(routingConfig.Nats)
+		teardown.Add("close NATS connection", registrar.Close)
+
+		prefix := helpers.RandomName()
+		var routes []nats.Route
+		var urls []string
+		for i := 0; i < routingConfig.Perf.RouteTableSize; i++ {
+			host := fmt.Sprintf("%s-%d.%s", prefix, i, routingConfig.AppsDomain)
+			routes = append(routes, nats.Route{
+				Host: backend.HostIP,
+				Port: backend.HostPort,
+				Uris: []string{host},
+			})
+			urls = append(urls, fmt.Sprintf("%s%s/", routingConfig.Protocol(), host))
+		}
+
+		Expect(len(routes)).To(BeNumerically(">", ROUTE_TABLE_BASELINE_SIZE), "route_table_size is too small")
+
+		client := newHttpClient(routingConfig.Perf.Concurrency)
+		profile := helpers.LoadProfile{
+			Duration:    routingConfig.Perf.Duration,
+			Concurrency: routingConfig.Perf.Concurrency,
+		}
+
+		By("measuring lookups in a small table")
+		stopBaseline := registrar.RegisterEvery(ROUTE_TABLE_REFRESH_INTERVAL, routes[:ROUTE_TABLE_BASELINE_SIZE]...)
+		baselineUrls := urls[:ROUTE_TABLE_BASELINE_SIZE]
+		Eventually(func() error {
+			return allServed(client, baselineUrls)
+		}, DEFAULT_TIMEOUT, ROUTE_TABLE_POLLING_INTERVAL).Should(Succeed())
+		baseline := driveProfile(profile, randomHostRequests(client, baselineUrls))
+		report(fmt.Sprintf("lookups among %d routes", ROUTE_TABLE_BASELINE_SIZE), baseline)
+		stopBaseline()
+
+		By(fmt.Sprintf("registering %d routes", len(routes)))
+		registrationSla := time.Duration(routingConfig.Perf.RouteTableRegistrationSla) * time.Second
+		registrationStart := time.Now()
+		stopRefreshing := registrar.RegisterEvery(ROUTE_TABLE_REFRESH_INTERVAL, routes...)
+		refreshing := true
+		defer func() {
+			if refreshing {
+				stopRefreshing()
+			}
+		}()
+
+		samples := sampleUrls(urls)
+		Eventually(func() error {
+			return allServed(client, samples)
+		}, registrationSla, ROUTE_TABLE_POLLING_INTERVAL).Should(Succeed(), "routes were not all registered within %s", registrationSla)
+		fmt.Fprintf(GinkgoWriter, "\n%d routes registered in %s\n", len(routes), time.Since(registrationStart))
+
+		By(fmt.Sprintf("measuring lookups among %d routes", len(routes)))
+		atScale := driveProfile(profile, randomHostRequests(client, urls))
+		report(fmt.Sprintf("lookups among %d routes", len(routes)), atScale)
+		fmt.Fprintf(GinkgoWriter, "\np99 lookup latency grew from %s to %s\n", baseline.Percentile(0.99), atScale.Percentile(0.99))
+		expectWithinThresholds(fmt.Sprintf("lookups among %d routes", len(routes)), atScale)
+
+		By("letting gorouter prune the routes")
+		stopRefreshing()
+		refreshing = false
+		pruneSla := time.Duration(routingConfig.Perf.RouteTablePruneSla) * time.Second
+		pruneStart := time.Now()
+		Eventually(func() error {
+			return allPruned(client, samples)
+		}, pruneSla, ROUTE_TABLE_POLLING_INTERVAL).Should(Succeed(), "routes were not all pruned within %s", pruneSla)
+		fmt.Fprintf(GinkgoWriter, "\n%d routes pruned in %s\n", len(routes), time.Since(pruneStart))
+	})
+})
+
+// randomHostRequests has every request pick one of urls at random, so the
+// load spreads over the whole routing table.
+func randomHostRequests(client *http.Client, urls []string) func(int) loadgen.Request {
+	return func(int) loadgen.Request {
+		return func(ctx context.Context) error {
+			return loadgen.HTTPGet(client, urls[rand.Intn(len(urls))])(ctx)
+		}
+	}
+}
+
+// sampleUrls picks ROUTE_TABLE_SAMPLES of urls, always including the last
+// one, which is registered last.
+func sampleUrls(urls []string) []string {
+	samples := []string{urls[len(urls)-1]}
+	for _, i := range rand.Perm(len(urls) - 1) {
+		if len(samples) == ROUTE_TABLE_SAMPLES {
+			break
+		}
+		samples = append(samples, urls[i])
+	}
+	return samples
+}
+
+// allServed requests each of urls once and returns the first failure.
+func allServed(client *http.Client, urls []string) error {
+	for _, url := range urls {
+		err := httpRequest(client, url)
+		if err != nil {
+			return fmt.Errorf("%s: %s", url, err)
+		}
+	}
+	return nil
+}
+
+// allPruned fails unless gorouter answers each of urls with 404, its answer
+// for unknown routes.
+func allPruned(client *http.Client, urls []string) error {
+	for _, url := range urls {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("%s still answers with status code %d", url, resp.StatusCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package perf_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// This spec keeps moderate load on gorouter and the tcp routers for
+// Perf.SoakDuration. After every check interval it asserts the error rate of
+// that interval and that both routes still lead to the instances they led to
+// when the soak started, so the apps must not be restarted while it runs.
+var _ = Describe("Soak", func() {
+	var (
+		httpApp  string
+		tcpApp   string
+		teardown *helpers.Teardown
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+		if routingConfig.Perf.SoakDuration == "" {
+			Skip("Skipping this test because Config.Perf.SoakDuration is not set.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+		httpApp = routing_helpers.GenerateAppName()
+		tcpApp = routing_helpers.GenerateAppName()
+		for _, app := range []string{httpApp, tcpApp} {
+			app := app
+			teardown.Add("delete app "+app, func() {
+				routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+			})
+			teardown.Add("report app "+app, func() {
+				routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+			})
+		}
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("keeps routes intact and errors low under continuous load", func() {
+		helpers.PushGoAppNoStart(routingConfig, httpApp, assets.NewAssets().TcpSampleGolang, routingConfig.AppsDomain, CF_PUSH_TIMEOUT, "256M", "", "-s", "cflinuxfs3")
+		routing_helpers.EnableDiego(httpApp, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(httpApp, DEFAULT_TIMEOUT)
+
+		appUrl := fmt.Sprintf("%s%s.%s/", routingConfig.Protocol(), httpApp, routingConfig.AppsDomain)
+		client := newHttpClient(routingConfig.Perf.Concurrency)
+		var httpInstance string
+		Eventually(func() (err error) {
+			httpInstance, err = servingInstance(client, appUrl)
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Succeed())
+
+		externalPort := pushTcpBackend(tcpApp, assets.NewAssets().TcpDropletReceiver, "tcp-droplet-receiver --serverId=soak", TCP_BACKEND_PORT, teardown)
+		tcpBackends := tcpRouteMappingsForPort(externalPort)
+		Expect(tcpBackends).To(HaveLen(1))
+
+		concurrency := routingConfig.Perf.Concurrency / 2
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		interval := time.Duration(routingConfig.Perf.SoakCheckInterval) * time.Second
+		end := time.Now().Add(routingConfig.Perf.SoakPeriod())
+
+		for check := 1; time.Now().Before(end); check++ {
+			var httpResult, tcpResult loadgen.Result
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				httpResult = driveLoad(concurrency, interval, func(int) loadgen.Request {
+					return loadgen.HTTPGet(client, appUrl)
+				})
+			}()
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				tcpResult = driveLoad(concurrency, interval, func(worker int) loadgen.Request {
+					return tcpEcho(routingConfig.Addresses[worker%len(routingConfig.Addresses)], externalPort)
+				})
+			}()
+			wg.Wait()
+
+			httpScenario := fmt.Sprintf("soak check %d gorouter", check)
+			tcpScenario := fmt.Sprintf("soak check %d tcp router", check)
+			report(httpScenario, httpResult)
+			report(tcpScenario, tcpResult)
+			expectSoakErrorRate(httpResult, httpScenario)
+			expectSoakErrorRate(tcpResult, tcpScenario)
+
+			instance, err := servingInstance(client, appUrl)
+			Expect(err).NotTo(HaveOccurred(), "%s route", httpScenario)
+			Expect(instance).To(Equal(httpInstance), "%s route leads to another instance", httpScenario)
+
+			portMappings := tcpRouteMappingsForPort(externalPort)
+			Expect(portMappings).To(HaveLen(1), "%s routing table", tcpScenario)
+			Expect(portMappings[0].HostIP).To(Equal(tcpBackends[0].HostIP), "%s routing table", tcpScenario)
+			Expect(portMappings[0].HostPort).To(Equal(tcpBackends[0].HostPort), "%s routing table", tcpScenario)
+		}
+	})
+})
+
+// expectSoakErrorRate allows no errors at all unless
+// PerfThresholds.MaxErrorRate is set, since over hours even a rare failure is
+// worth looking at.
+func expectSoakErrorRate(result loadgen.Result, scenario string) {
+	Expect(result.Requests).To(BeNumerically(">", 0), "%s sent no requests", scenario)
+	Expect(result.ErrorRate()).To(BeNumerically("<=", routingConfig.PerfThresholds.MaxErrorRate), "%s error rate", scenario)
+}
+
+// servingInstance returns the guid of the golang app instance answering url.
+func servingInstance(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url + "instance")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var report struct {
+		Guid string `json:"guid"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	return report.Guid, err
+}
@@ -0,0 +1,187 @@
+package perf_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/loadgen"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	TCP_SCALE_TTL              = 120
+	TCP_SCALE_REFRESH_INTERVAL = 30 * time.Second
+	TCP_SCALE_MESSAGE_INTERVAL = time.Second
+	TCP_SCALE_CHURN_PORTS      = 10
+	TCP_SCALE_CHURN_INTERVAL   = 5 * time.Second
+)
+
+// This spec maps Config.TcpMappingScaleCount external ports to one backend
+// and holds a connection to each, sending one message a second. It first
+// measures that load on its own, then again while other mappings are
+// created and deleted every few seconds, so the tcp routers keep reloading
+// their configuration. Both phases must carry the full aggregate message
+// rate, and the reloads must not cost the held connections any errors.
+var _ = Describe("TCP mapping churn", func() {
+	var (
+		appName  string
+		teardown *helpers.Teardown
+	)
+
+	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+		if !routingConfig.Perf.IncludeTcpMappingChurn {
+			Skip("Skipping this test because Config.Perf.IncludeTcpMappingChurn is set to `false`.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+	})
+
+	AfterEach(func() {
+		Expect(teardown.Run()).NotTo(HaveOccurred())
+	})
+
+	It("carries light traffic on every mapping while mappings churn", func() {
+		externalPort := pushTcpBackend(appName, assets.NewAssets().TcpDropletReceiver, "tcp-droplet-receiver --serverId=scale", TCP_BACKEND_PORT, teardown)
+		backends := tcpRouteMappingsForPort(externalPort)
+		Expect(backends).NotTo(BeEmpty())
+		backend := backends[0]
+
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).NotTo(HaveOccurred())
+
+		var mappings []models.TcpRouteMapping
+		for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, routingConfig.TcpMappingScaleCount+TCP_SCALE_CHURN_PORTS) {
+			mappings = append(mappings, models.NewTcpRouteMapping(routerGroup.Guid, port, backend.HostIP, backend.HostPort, TCP_SCALE_TTL))
+		}
+		loaded, churned := mappings[:routingConfig.TcpMappingScaleCount], mappings[routingConfig.TcpMappingScaleCount:]
+		teardown.Add("delete scale mappings", func() {
+			Expect(routingApiClient.DeleteTcpRouteMappings(mappings)).To(Succeed())
+		})
+
+		stopRefreshing := refreshTcpRouteMappings(loaded)
+		defer stopRefreshing()
+
+		timeout := time.Duration(routingConfig.TcpMappingScaleTimeout) * time.Second
+		addresses := make([]string, len(loaded))
+		for i, mapping := range loaded {
+			addresses[i] = fmt.Sprintf("%s:%d", routingConfig.Addresses[i%len(routingConfig.Addresses)], mapping.ExternalPort)
+		}
+		Eventually(func() error {
+			for _, address := range addresses {
+				err := shortLivedConnection(address)
+				if err != nil {
+					return fmt.Errorf("%s: %s", address, err)
+				}
+			}
+			return nil
+		}, timeout, DEFAULT_POLLING_INTERVAL).Should(Succeed(), "mappings were not all served within %s", timeout)
+
+		expectedRate := float64(len(loaded)) / TCP_SCALE_MESSAGE_INTERVAL.Seconds()
+		runPhase := func(scenario string) loadgen.Result {
+			result := loadgen.Pool{
+				Workers:  len(addresses),
+				Duration: time.Duration(routingConfig.Perf.Duration) * time.Second,
+				Interval: TCP_SCALE_MESSAGE_INTERVAL,
+				NewWorker: func(worker int) loadgen.Request {
+					return loadgen.TCPEcho(addresses[worker], DEFAULT_CONNECT_TIMEOUT, DEFAULT_RW_TIMEOUT)
+				},
+			}.Run(context.Background())
+			report(scenario, result)
+			Expect(result.Throughput()).To(BeNumerically(">=", 0.9*expectedRate), "%s aggregate throughput", scenario)
+			return result
+		}
+
+		steady := runPhase(fmt.Sprintf("%d tcp mappings", len(loaded)))
+		expectSoakErrorRate(steady, fmt.Sprintf("%d tcp mappings", len(loaded)))
+
+		stopChurning := churnTcpRouteMappings(churned)
+		underChurn := runPhase(fmt.Sprintf("%d tcp mappings under churn", len(loaded)))
+		reloads := stopChurning()
+		fmt.Fprintf(GinkgoWriter, "\n%d mapping changes during the churn phase, p99 %s before and %s during\n",
+			reloads, steady.Percentile(0.99), underChurn.Percentile(0.99))
+
+		expectSoakErrorRate(underChurn, fmt.Sprintf("%d tcp mappings under churn", len(loaded)))
+	})
+})
+
+// refreshTcpRouteMappings upserts mappings now and then every
+// TCP_SCALE_REFRESH_INTERVAL, as a route emitter would, so they outlive
+// their TTL, until the returned function is called. A refresh that has not
+// returned by the next tick is abandoned.
+func refreshTcpRouteMappings(mappings []models.TcpRouteMapping) func() {
+	Expect(routingApiClient.UpsertTcpRouteMappings(mappings)).To(Succeed())
+
+	contextClient := helpers.NewContextClient(routingApiClient)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(TCP_SCALE_REFRESH_INTERVAL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), TCP_SCALE_REFRESH_INTERVAL)
+				contextClient.UpsertTcpRouteMappings(ctx, mappings)
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// churnTcpRouteMappings alternately creates and deletes mappings every
+// TCP_SCALE_CHURN_INTERVAL until the returned function is called, which
+// returns how many changes it made.
+func churnTcpRouteMappings(mappings []models.TcpRouteMapping) func() int {
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	changes := 0
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(TCP_SCALE_CHURN_INTERVAL)
+		defer ticker.Stop()
+		for present := false; ; present = !present {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+
+			var err error
+			if present {
+				err = routingApiClient.DeleteTcpRouteMappings(mappings)
+			} else {
+				err = routingApiClient.UpsertTcpRouteMappings(mappings)
+			}
+			if err == nil {
+				changes++
+			}
+		}
+	}()
+
+	return func() int {
+		close(done)
+		wg.Wait()
+		return changes
+	}
+}
@@ -0,0 +1,147 @@
+package routing_api_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The parity specs run the same sequence against whichever store backs the
+// Routing API and record what a client observes. The transcript has to match
+// the expected one on every backend, and is written to the artifacts
+// directory so runs against different backends can be compared directly.
+var _ = Describe("Backend Parity", func() {
+	var (
+		scenario   string
+		transcript []string
+		route      models.Route
+		mapping    models.TcpRouteMapping
+	)
+
+	record := func(format string, args ...interface{}) {
+		transcript = append(transcript, fmt.Sprintf(format, args...))
+	}
+
+	BeforeEach(func() {
+		if routingConfig.RoutingApiBackend == "" {
+			Skip("Skipping this test because Config.RoutingApiBackend is not set.")
+		}
+
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", 60)
+		port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+		mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+		scenario = ""
+		transcript = nil
+	})
+
+	AfterEach(func() {
+		if httpRoutesEnabled() {
+			err := routingApiClient.DeleteRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+
+		if routingConfig.ArtifactsDirectory != "" && len(transcript) > 0 {
+			name := fmt.Sprintf("routing_api_parity_%s_%s_%d.txt", routingConfig.RoutingApiBackend, scenario, GinkgoParallelNode())
+			err := ioutil.WriteFile(filepath.Join(routingConfig.ArtifactsDirectory, name), []byte(strings.Join(transcript, "\n")+"\n"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("shows the same http route lifecycle on every backend", func() {
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+		scenario = "http_routes"
+
+		source, events := subscribeToHttpEvents(route.Route)
+		defer source.Close()
+
+		record("upsert: %s", errorType(routingApiClient.UpsertRoutes([]models.Route{route})))
+		record("listed: %t", httpRouteListed(route))
+		record("upsert again: %s", errorType(routingApiClient.UpsertRoutes([]models.Route{route})))
+		record("delete: %s", errorType(routingApiClient.DeleteRoutes([]models.Route{route})))
+		record("listed: %t", httpRouteListed(route))
+		record("delete again: %s", errorType(routingApiClient.DeleteRoutes([]models.Route{route})))
+
+		for i := 0; i < 3; i++ {
+			var event routing_api.Event
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&event))
+			record("event: %s", event.Action)
+		}
+
+		Expect(transcript).To(Equal([]string{
+			"upsert: ok",
+			"listed: true",
+			"upsert again: ok",
+			"delete: ok",
+			"listed: false",
+			"delete again: ok",
+			"event: Upsert",
+			"event: Upsert",
+			"event: Delete",
+		}))
+	})
+
+	It("shows the same tcp route mapping lifecycle on every backend", func() {
+		scenario = "tcp_route_mappings"
+
+		source, events := subscribeToTcpEvents(mapping.ExternalPort)
+		defer source.Close()
+
+		record("upsert: %s", errorType(routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})))
+		record("listed: %t", tcpRouteMappingListed(mapping))
+		record("upsert again: %s", errorType(routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})))
+		record("delete: %s", errorType(routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})))
+		record("listed: %t", tcpRouteMappingListed(mapping))
+		record("delete again: %s", errorType(routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})))
+
+		invalid := mapping
+		invalid.HostIP = ""
+		record("upsert invalid: %s", errorType(routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{invalid})))
+
+		for i := 0; i < 3; i++ {
+			var event routing_api.TcpEvent
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&event))
+			record("event: %s", event.Action)
+		}
+
+		Expect(transcript).To(Equal([]string{
+			"upsert: ok",
+			"listed: true",
+			"upsert again: ok",
+			"delete: ok",
+			"listed: false",
+			"delete again: ok",
+			"upsert invalid: " + routing_api.TcpRouteMappingInvalidError,
+			"event: Upsert",
+			"event: Upsert",
+			"event: Delete",
+		}))
+	})
+})
+
+// errorType reduces an API result to what the parity transcript compares: ok,
+// the Routing API error type, or a generic failure.
+func errorType(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if apiErr, ok := err.(routing_api.Error); ok {
+		return apiErr.Type
+	}
+	return "error"
+}
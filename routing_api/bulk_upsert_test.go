@@ -0,0 +1,157 @@
+package routing_api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const BULK_ROUTE_COUNT = 500
+
+var _ = Describe("Bulk Upserts", func() {
+	var (
+		prefix string
+	)
+
+	BeforeEach(func() {
+		prefix = helpers.RandomName()
+	})
+
+	Context("HTTP routes", func() {
+		var (
+			routes []models.Route
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			routes = httpRoutes(prefix, BULK_ROUTE_COUNT)
+		})
+
+		AfterEach(func() {
+			// Only delete what was written; an oversized request would be rejected again
+			if written := httpRoutesWithPrefix(prefix); len(written) > 0 {
+				err := routingApiClient.DeleteRoutes(written)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("persists every route of a single request", func() {
+			err := routingApiClient.UpsertRoutes(routes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(routeNames(httpRoutesWithPrefix(prefix))).To(ConsistOf(routeNames(routes)))
+		})
+
+		Context("when the request exceeds the maximum payload size", func() {
+			BeforeEach(func() {
+				if routingConfig.RoutingApiMaxPayloadBytes <= 0 {
+					Skip("Skipping this test because Config.RoutingApiMaxPayloadBytes is not set.")
+				}
+				routes = oversizedHttpRoutes(prefix, routingConfig.RoutingApiMaxPayloadBytes)
+			})
+
+			It("rejects the request with an error and writes none of the routes", func() {
+				err := routingApiClient.UpsertRoutes(routes)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+				Expect(err.(routing_api.Error).Message).ToNot(BeEmpty())
+
+				Expect(httpRoutesWithPrefix(prefix)).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			mappings []models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Backends differ, so all mappings can share one external port
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mappings = nil
+			for i := 0; i < BULK_ROUTE_COUNT; i++ {
+				mappings = append(mappings, models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", uint16(10000+i), 60))
+			}
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings(mappings)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("persists every mapping of a single request", func() {
+			err := routingApiClient.UpsertTcpRouteMappings(mappings)
+			Expect(err).ToNot(HaveOccurred())
+
+			listed, err := routingApiClient.TcpRouteMappings()
+			Expect(err).ToNot(HaveOccurred())
+
+			var hostPorts []uint16
+			for _, mapping := range listed {
+				if mapping.RouterGroupGuid == mappings[0].RouterGroupGuid && mapping.ExternalPort == mappings[0].ExternalPort {
+					hostPorts = append(hostPorts, mapping.HostPort)
+				}
+			}
+
+			var expected []uint16
+			for _, mapping := range mappings {
+				expected = append(expected, mapping.HostPort)
+			}
+			Expect(hostPorts).To(ConsistOf(expected))
+		})
+	})
+})
+
+func httpRoutes(prefix string, count int) []models.Route {
+	var routes []models.Route
+	for i := 0; i < count; i++ {
+		routes = append(routes, models.NewRoute(fmt.Sprintf("%s-%d.example.com", prefix, i), 65340, "1.2.3.4", "", "", 60))
+	}
+	return routes
+}
+
+// oversizedHttpRoutes returns routes whose JSON encoding is larger than
+// maxBytes.
+func oversizedHttpRoutes(prefix string, maxBytes int) []models.Route {
+	routes := httpRoutes(prefix, 1)
+	encoded, err := json.Marshal(routes)
+	Expect(err).ToNot(HaveOccurred())
+
+	return httpRoutes(prefix, maxBytes/len(encoded)+2)
+}
+
+func httpRoutesWithPrefix(prefix string) []models.Route {
+	routes, err := routingApiClient.Routes()
+	Expect(err).ToNot(HaveOccurred())
+
+	var result []models.Route
+	for _, route := range routes {
+		if strings.HasPrefix(route.Route, prefix) {
+			result = append(result, route)
+		}
+	}
+	return result
+}
+
+func routeNames(routes []models.Route) []string {
+	var names []string
+	for _, route := range routes {
+		names = append(names, route.Route)
+	}
+	return names
+}
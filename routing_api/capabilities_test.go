@@ -0,0 +1,39 @@
+package routing_api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capabilities", func() {
+	It("discovers and records what the Routing API offers", func() {
+		Expect(capabilities).To(HaveKey(helpers.HttpRoutesCapability))
+		Expect(capabilities).To(HaveKey(helpers.RouterGroupCreationCapability))
+
+		var names []string
+		for name := range capabilities {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(GinkgoWriter, "\nRouting API capability %s: %t\n", name, capabilities[name])
+		}
+
+		if routingConfig.ArtifactsDirectory != "" {
+			encoded, err := json.MarshalIndent(capabilities, "", "  ")
+			Expect(err).ToNot(HaveOccurred())
+
+			name := fmt.Sprintf("routing_api_capabilities_%d.json", GinkgoParallelNode())
+			err = ioutil.WriteFile(filepath.Join(routingConfig.ArtifactsDirectory, name), encoded, 0644)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,111 @@
+package routing_api_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	STRESS_WRITERS    = 10
+	STRESS_POOL_SIZE  = 20
+	STRESS_BATCH_SIZE = 5
+	STRESS_DURATION   = 30 * time.Second
+)
+
+var _ = Describe("Concurrent Writers", func() {
+	var (
+		prefix string
+		pool   []models.Route
+	)
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+
+		prefix = fmt.Sprintf("stress-%s", helpers.RandomName())
+		pool = httpRoutes(prefix, STRESS_POOL_SIZE)
+	})
+
+	AfterEach(func() {
+		err := routingApiClient.DeleteRoutes(pool)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("leaves no ghosts and loses no deletes after overlapping writes", func() {
+		var (
+			wg       sync.WaitGroup
+			errMutex sync.Mutex
+			errs     []string
+		)
+		deadline := time.Now().Add(STRESS_DURATION)
+
+		for i := 0; i < STRESS_WRITERS; i++ {
+			wg.Add(1)
+			go func(writer int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				random := rand.New(rand.NewSource(GinkgoRandomSeed() + int64(writer)))
+				for time.Now().Before(deadline) {
+					batch := make([]models.Route, 0, STRESS_BATCH_SIZE)
+					for _, j := range random.Perm(STRESS_POOL_SIZE)[:STRESS_BATCH_SIZE] {
+						batch = append(batch, pool[j])
+					}
+
+					var err error
+					if random.Intn(2) == 0 {
+						err = routingApiClient.UpsertRoutes(batch)
+					} else {
+						err = routingApiClient.DeleteRoutes(batch)
+					}
+					if err != nil {
+						errMutex.Lock()
+						errs = append(errs, fmt.Sprintf("writer %d: %s", writer, err))
+						errMutex.Unlock()
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		Expect(errs).To(BeEmpty())
+
+		// Settle every route with one last write whose outcome is known: odd
+		// routes are deleted and even ones upserted, still concurrently.
+		var kept []models.Route
+		for i, route := range pool {
+			if i%2 == 0 {
+				kept = append(kept, route)
+			}
+		}
+
+		for i, route := range pool {
+			wg.Add(1)
+			go func(i int, route models.Route) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				if i%2 == 0 {
+					Expect(routingApiClient.UpsertRoutes([]models.Route{route})).To(Succeed())
+				} else {
+					Expect(routingApiClient.DeleteRoutes([]models.Route{route})).To(Succeed())
+				}
+			}(i, route)
+		}
+		wg.Wait()
+
+		Expect(routeNames(httpRoutesWithPrefix(prefix))).To(ConsistOf(routeNames(kept)))
+		Consistently(func() []string {
+			return routeNames(httpRoutesWithPrefix(prefix))
+		}, 10*time.Second, DEFAULT_POLLING_INTERVAL).Should(ConsistOf(routeNames(kept)))
+	})
+})
@@ -0,0 +1,89 @@
+package routing_api_test
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Proxies and load balancers close connections that sit idle, so an event
+// stream without route changes has to carry keep-alive frames often enough
+// to stay open.
+var _ = Describe("Event Stream Keep-Alive", func() {
+	var (
+		idleTimeout time.Duration
+		route       models.Route
+		resp        *http.Response
+	)
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+
+		idleTimeout = time.Duration(routingConfig.RoutingApiEventIdleTimeout) * time.Second
+		route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", 60)
+
+		httpClient, baseUrl := helpers.NewRoutingApiHttpClient(routingConfig)
+		req, err := http.NewRequest("GET", baseUrl+"/routing/v1/events", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Authorization", "bearer "+routingApiToken)
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err = httpClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+
+	AfterEach(func() {
+		resp.Body.Close()
+		err := routingApiClient.DeleteRoutes([]models.Route{route})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("sends frames more often than the idle timeout and stays open", func() {
+		lines := make(chan string, 100)
+		go func() {
+			defer close(lines)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); line != "" {
+					lines <- line
+				}
+			}
+		}()
+
+		var (
+			longestGap time.Duration
+			frames     int
+		)
+		lastFrame := time.Now()
+		deadline := lastFrame.Add(2 * idleTimeout)
+		for time.Now().Before(deadline) {
+			select {
+			case _, ok := <-lines:
+				Expect(ok).To(BeTrue(), "event stream closed after %d frames", frames)
+				if gap := time.Since(lastFrame); gap > longestGap {
+					longestGap = gap
+				}
+				lastFrame = time.Now()
+				frames++
+			case <-time.After(idleTimeout):
+				Fail(fmt.Sprintf("no frame on the event stream for %s", idleTimeout))
+			}
+		}
+		fmt.Fprintf(GinkgoWriter, "\nReceived %d frames, longest gap %s\n", frames, longestGap)
+
+		err := routingApiClient.UpsertRoutes([]models.Route{route})
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(lines, DEFAULT_TIMEOUT).Should(Receive(ContainSubstring(route.Route)))
+	})
+})
@@ -0,0 +1,128 @@
+package routing_api_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The event stream does not replay what happened while a subscriber was
+// disconnected. A subscriber resynchronizes by subscribing again and then
+// listing, so nothing written between the two is lost, and applying later
+// events on top of the listing.
+var _ = Describe("Event Resync After Disconnect", func() {
+	var (
+		prefix  string
+		initial []models.Route
+	)
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+
+		prefix = fmt.Sprintf("resync-%s", helpers.RandomName())
+		initial = httpRoutes(prefix, 3)
+
+		err := routingApiClient.UpsertRoutes(initial)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := routingApiClient.DeleteRoutes(httpRoutesWithPrefix(prefix))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rebuilds route state from a listing and new events without gaps or duplicates", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		source, err := helpers.NewContextClient(routingApiClient).SubscribeToEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		events := prefixedEvents(helpers.Events(ctx, source), prefix)
+
+		kept := httpRoutes(prefix+"-kept", 1)
+		err = routingApiClient.UpsertRoutes(kept)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive())
+
+		cancel()
+		Eventually(events, DEFAULT_TIMEOUT).Should(BeClosed())
+
+		err = routingApiClient.DeleteRoutes(initial[:1])
+		Expect(err).ToNot(HaveOccurred())
+		missed := httpRoutes(prefix+"-missed", 2)
+		err = routingApiClient.UpsertRoutes(missed)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx, cancel = context.WithCancel(context.Background())
+		defer cancel()
+		source, err = helpers.NewContextClient(routingApiClient).SubscribeToEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		events = prefixedEvents(helpers.Events(ctx, source), prefix)
+
+		state := map[string]models.Route{}
+		for _, route := range httpRoutesWithPrefix(prefix) {
+			Expect(state).ToNot(HaveKey(route.Route), "route %s listed twice", route.Route)
+			state[route.Route] = route
+		}
+
+		added := httpRoutes(prefix+"-added", 1)
+		err = routingApiClient.UpsertRoutes(added)
+		Expect(err).ToNot(HaveOccurred())
+		err = routingApiClient.DeleteRoutes(initial[1:2])
+		Expect(err).ToNot(HaveOccurred())
+
+		var received []string
+		for len(received) < 2 {
+			var event routing_api.Event
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&event))
+			received = append(received, fmt.Sprintf("%s %s", event.Action, event.Route.Route))
+
+			switch event.Action {
+			case "Upsert":
+				state[event.Route.Route] = event.Route
+			case "Delete", "Expire":
+				delete(state, event.Route.Route)
+			}
+		}
+		Expect(received).To(ConsistOf(
+			"Upsert "+added[0].Route,
+			"Delete "+initial[1].Route,
+		))
+		Consistently(events, 5*time.Second).ShouldNot(Receive())
+
+		expected := []string{initial[2].Route, kept[0].Route, missed[0].Route, missed[1].Route, added[0].Route}
+		Expect(routeNamesOf(state)).To(ConsistOf(expected))
+		Expect(routeNamesOf(state)).To(ConsistOf(routeNames(httpRoutesWithPrefix(prefix))))
+	})
+})
+
+// prefixedEvents passes on the events for routes named with prefix.
+func prefixedEvents(events <-chan routing_api.Event, prefix string) <-chan routing_api.Event {
+	filtered := make(chan routing_api.Event, 100)
+	go func() {
+		defer close(filtered)
+		for event := range events {
+			if strings.HasPrefix(event.Route.Route, prefix) {
+				filtered <- event
+			}
+		}
+	}()
+	return filtered
+}
+
+func routeNamesOf(state map[string]models.Route) []string {
+	var names []string
+	for name := range state {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,105 @@
+package routing_api_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const DELETE_RETRIES = 3
+
+// Deleting a route or mapping the store does not hold is not an error: the
+// API drops the KeyNotFound and answers 204 with an empty body, every time.
+// Automation relies on this to retry deletes safely.
+var _ = Describe("Idempotent Deletes", func() {
+	Context("HTTP routes", func() {
+		var (
+			existing models.Route
+			missing  models.Route
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			name := helpers.RandomName()
+			existing = models.NewRoute(fmt.Sprintf("%s-existing.example.com", name), 65340, "1.2.3.4", "", "", 60)
+			missing = models.NewRoute(fmt.Sprintf("%s-missing.example.com", name), 65340, "1.2.3.4", "", "", 60)
+
+			err := routingApiClient.UpsertRoutes([]models.Route{existing})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteRoutes([]models.Route{existing})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("answers a delete of a missing route the same way every time", func() {
+			for i := 0; i < DELETE_RETRIES; i++ {
+				resp, body := routingApiRequest("DELETE", "/routing/v1/routes", []models.Route{missing})
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent), "attempt %d", i)
+				Expect(body).To(BeEmpty(), "attempt %d", i)
+			}
+			Expect(httpRouteListed(existing)).To(BeTrue())
+		})
+
+		It("deletes the existing routes of a batch that also holds missing ones", func() {
+			for i := 0; i < DELETE_RETRIES; i++ {
+				resp, body := routingApiRequest("DELETE", "/routing/v1/routes", []models.Route{missing, existing})
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent), "attempt %d", i)
+				Expect(body).To(BeEmpty(), "attempt %d", i)
+				Expect(httpRouteListed(existing)).To(BeFalse(), "attempt %d", i)
+			}
+		})
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			existing models.TcpRouteMapping
+			missing  models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			ports := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 2)
+			existing = models.NewTcpRouteMapping(routerGroup.Guid, ports[0], "1.2.3.4", 60000, 60)
+			missing = models.NewTcpRouteMapping(routerGroup.Guid, ports[1], "1.2.3.4", 60000, 60)
+
+			err = routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{existing})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{existing})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("answers a delete of a missing mapping the same way every time", func() {
+			for i := 0; i < DELETE_RETRIES; i++ {
+				resp, body := routingApiRequest("POST", "/routing/v1/tcp_routes/delete", []models.TcpRouteMapping{missing})
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent), "attempt %d", i)
+				Expect(body).To(BeEmpty(), "attempt %d", i)
+			}
+			Expect(tcpRouteMappingListed(existing)).To(BeTrue())
+		})
+
+		It("deletes the existing mappings of a batch that also holds missing ones", func() {
+			for i := 0; i < DELETE_RETRIES; i++ {
+				resp, body := routingApiRequest("POST", "/routing/v1/tcp_routes/delete", []models.TcpRouteMapping{missing, existing})
+				Expect(resp.StatusCode).To(Equal(http.StatusNoContent), "attempt %d", i)
+				Expect(body).To(BeEmpty(), "attempt %d", i)
+				Expect(tcpRouteMappingListed(existing)).To(BeFalse(), "attempt %d", i)
+			}
+		})
+	})
+})
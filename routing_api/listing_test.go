@@ -0,0 +1,143 @@
+package routing_api_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const LISTED_ROUTE_COUNT = 200
+
+// The list endpoints return everything in a single response; there are no
+// pagination parameters. These specs check that response stays complete and
+// free of duplicates while other writers are busy, and cover the one filter
+// the API offers, isolation segments on tcp route mappings.
+var _ = Describe("Listing Routes", func() {
+	var (
+		prefix string
+	)
+
+	BeforeEach(func() {
+		prefix = helpers.RandomName()
+	})
+
+	Context("HTTP routes", func() {
+		var (
+			routes []models.Route
+			churn  []models.Route
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			routes = httpRoutes(prefix, LISTED_ROUTE_COUNT)
+			churn = httpRoutes(prefix+"-churn", 20)
+
+			err := routingApiClient.UpsertRoutes(routes)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteRoutes(append(routes, churn...))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("lists every route exactly once while routes are being modified", func() {
+			stop := make(chan struct{})
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					err := routingApiClient.UpsertRoutes(churn)
+					Expect(err).ToNot(HaveOccurred())
+					err = routingApiClient.DeleteRoutes(churn)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			}()
+			defer func() {
+				close(stop)
+				wg.Wait()
+			}()
+
+			expected := routeNames(routes)
+			for i := 0; i < 10; i++ {
+				listed := routeNames(httpRoutesWithPrefix(prefix + "-"))
+				var stable []string
+				for _, name := range listed {
+					if !strings.HasPrefix(name, prefix+"-churn-") {
+						stable = append(stable, name)
+					}
+				}
+				Expect(stable).To(ConsistOf(expected))
+			}
+		})
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			mappings         []models.TcpRouteMapping
+			isolationSegment string
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			isolationSegment = fmt.Sprintf("rats-%s", prefix)
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mappings = nil
+			for i := 0; i < LISTED_ROUTE_COUNT; i++ {
+				mapping := models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", uint16(10000+i), 60)
+				mapping.IsolationSegment = isolationSegment
+				mappings = append(mappings, mapping)
+			}
+
+			err = routingApiClient.UpsertTcpRouteMappings(mappings)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings(mappings)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("lists only the mappings of the requested isolation segment, each once", func() {
+			filtered, err := routingApiClient.FilteredTcpRouteMappings([]string{isolationSegment})
+			Expect(err).ToNot(HaveOccurred())
+
+			var hostPorts []uint16
+			for _, mapping := range filtered {
+				Expect(mapping.IsolationSegment).To(Equal(isolationSegment))
+				hostPorts = append(hostPorts, mapping.HostPort)
+			}
+
+			var expected []uint16
+			for _, mapping := range mappings {
+				expected = append(expected, mapping.HostPort)
+			}
+			Expect(hostPorts).To(ConsistOf(expected))
+		})
+
+		It("lists nothing for an isolation segment without mappings", func() {
+			filtered, err := routingApiClient.FilteredTcpRouteMappings([]string{isolationSegment + "-empty"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(filtered).To(BeEmpty())
+		})
+	})
+})
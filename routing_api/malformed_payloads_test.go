@@ -0,0 +1,134 @@
+package routing_api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// Each payload that parses carries a valid entry ahead of the invalid one, so
+// a rejected request must not have written anything.
+var _ = Describe("Malformed Payloads", func() {
+	var (
+		prefix string
+	)
+
+	BeforeEach(func() {
+		prefix = helpers.RandomName()
+	})
+
+	Context("HTTP routes", func() {
+		var (
+			valid string
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			valid = fmt.Sprintf(`{"route": "%s-valid.example.com", "port": 65340, "ip": "1.2.3.4", "ttl": 60}`, prefix)
+		})
+
+		AfterEach(func() {
+			if written := httpRoutesWithPrefix(prefix); len(written) > 0 {
+				err := routingApiClient.DeleteRoutes(written)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		DescribeTable("rejects the request with a useful error and writes nothing",
+			func(invalid func(prefix string) string) {
+				payload := fmt.Sprintf("[%s, %s]", valid, invalid(prefix))
+				resp, body := routingApiRawRequest("POST", "/routing/v1/routes", []byte(payload))
+				expectClientError(resp.StatusCode, body)
+
+				Expect(httpRoutesWithPrefix(prefix)).To(BeEmpty())
+			},
+			Entry("malformed JSON", func(prefix string) string {
+				return fmt.Sprintf(`{"route": "%s-invalid.example.com", "port":`, prefix)
+			}),
+			Entry("a port of the wrong type", func(prefix string) string {
+				return fmt.Sprintf(`{"route": "%s-invalid.example.com", "port": "65340", "ip": "1.2.3.4", "ttl": 60}`, prefix)
+			}),
+			Entry("a ttl of the wrong type", func(prefix string) string {
+				return fmt.Sprintf(`{"route": "%s-invalid.example.com", "port": 65340, "ip": "1.2.3.4", "ttl": "60"}`, prefix)
+			}),
+			Entry("a negative port", func(prefix string) string {
+				return fmt.Sprintf(`{"route": "%s-invalid.example.com", "port": -1, "ip": "1.2.3.4", "ttl": 60}`, prefix)
+			}),
+			Entry("a giant hostname", func(prefix string) string {
+				return fmt.Sprintf(`{"route": "%s-%s.example.com", "port": 65340, "ip": "1.2.3.4", "ttl": 60}`, prefix, strings.Repeat("a", 4096))
+			}),
+		)
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			validMapping models.TcpRouteMapping
+			valid        string
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			validMapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+			encoded, err := json.Marshal(validMapping)
+			Expect(err).ToNot(HaveOccurred())
+			valid = string(encoded)
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{validMapping})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		DescribeTable("rejects the request with a useful error and writes nothing",
+			func(invalid func(mapping models.TcpRouteMapping) string) {
+				payload := fmt.Sprintf("[%s, %s]", valid, invalid(validMapping))
+				resp, body := routingApiRawRequest("POST", "/routing/v1/tcp_routes/create", []byte(payload))
+				expectClientError(resp.StatusCode, body)
+
+				Expect(tcpRouteMappingListed(validMapping)).To(BeFalse())
+			},
+			Entry("malformed JSON", func(mapping models.TcpRouteMapping) string {
+				return fmt.Sprintf(`{"router_group_guid": "%s", "port":`, mapping.RouterGroupGuid)
+			}),
+			Entry("a port of the wrong type", func(mapping models.TcpRouteMapping) string {
+				return fmt.Sprintf(`{"router_group_guid": "%s", "port": "%d", "backend_ip": "1.2.3.4", "backend_port": 60001, "ttl": 60}`, mapping.RouterGroupGuid, mapping.ExternalPort)
+			}),
+			Entry("a negative external port", func(mapping models.TcpRouteMapping) string {
+				return fmt.Sprintf(`{"router_group_guid": "%s", "port": -1, "backend_ip": "1.2.3.4", "backend_port": 60001, "ttl": 60}`, mapping.RouterGroupGuid)
+			}),
+			Entry("a negative backend port", func(mapping models.TcpRouteMapping) string {
+				return fmt.Sprintf(`{"router_group_guid": "%s", "port": %d, "backend_ip": "1.2.3.4", "backend_port": -1, "ttl": 60}`, mapping.RouterGroupGuid, mapping.ExternalPort)
+			}),
+			Entry("a giant backend host", func(mapping models.TcpRouteMapping) string {
+				return fmt.Sprintf(`{"router_group_guid": "%s", "port": %d, "backend_ip": "%s.example.com", "backend_port": 60001, "ttl": 60}`, mapping.RouterGroupGuid, mapping.ExternalPort, strings.Repeat("a", 4096))
+			}),
+		)
+	})
+})
+
+// expectClientError checks the API answered 4xx with an error body naming
+// what went wrong.
+func expectClientError(statusCode int, body []byte) {
+	ExpectWithOffset(1, statusCode).To(BeNumerically(">=", 400))
+	ExpectWithOffset(1, statusCode).To(BeNumerically("<", 500))
+
+	var apiErr routing_api.Error
+	ExpectWithOffset(1, json.Unmarshal(body, &apiErr)).To(Succeed(), "error body: %s", body)
+	ExpectWithOffset(1, apiErr.Type).ToNot(BeEmpty())
+	ExpectWithOffset(1, apiErr.Message).ToNot(BeEmpty())
+}
@@ -0,0 +1,138 @@
+package routing_api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	MIGRATION_ROUTE_COUNT   = 50
+	MIGRATION_MAPPING_COUNT = 10
+)
+
+// migrationSnapshot is what the seed run observed through the Routing API
+// before the store was migrated.
+type migrationSnapshot struct {
+	Prefix           string                   `json:"prefix"`
+	Routes           []models.Route           `json:"routes"`
+	RouterGroups     []models.RouterGroup     `json:"router_groups"`
+	TcpRouteMappings []models.TcpRouteMapping `json:"tcp_route_mappings"`
+}
+
+var _ = Describe("etcd to SQL Migration", func() {
+	var (
+		migration *helpers.RoutingApiMigration
+	)
+
+	BeforeEach(func() {
+		migration = routingConfig.RoutingApiMigration
+		if migration == nil {
+			Skip("Skipping this test because Config.RoutingApiMigration is not set.")
+		}
+	})
+
+	Context("before the migration", func() {
+		BeforeEach(func() {
+			if migration.Phase != helpers.SeedMigrationPhase {
+				Skip("Skipping this test because Config.RoutingApiMigration.Phase is not seed.")
+			}
+		})
+
+		It("seeds routes and tcp route mappings and records the store", func() {
+			prefix := fmt.Sprintf("migration-%s", helpers.RandomName())
+			snapshot := migrationSnapshot{Prefix: prefix}
+
+			// http routes are only seeded where their endpoints are enabled
+			if httpRoutesEnabled() {
+				routes := httpRoutes(prefix, MIGRATION_ROUTE_COUNT)
+				for i := range routes {
+					routes[i].TTL = intPtr(migration.RouteTTL)
+				}
+				err := routingApiClient.UpsertRoutes(routes)
+				Expect(err).ToNot(HaveOccurred())
+
+				snapshot.Routes = httpRoutesWithPrefix(prefix)
+				Expect(snapshot.Routes).To(HaveLen(MIGRATION_ROUTE_COUNT))
+			}
+
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			var mappings []models.TcpRouteMapping
+			for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, MIGRATION_MAPPING_COUNT) {
+				mappings = append(mappings, models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, migration.RouteTTL))
+			}
+			err = routingApiClient.UpsertTcpRouteMappings(mappings)
+			Expect(err).ToNot(HaveOccurred())
+
+			snapshot.RouterGroups, err = routingApiClient.RouterGroups()
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, mapping := range mappings {
+				snapshot.TcpRouteMappings = append(snapshot.TcpRouteMappings, findTcpRouteMapping(mapping))
+			}
+
+			encoded, err := json.Marshal(snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			err = ioutil.WriteFile(migration.SnapshotFile, encoded, 0644)
+			Expect(err).ToNot(HaveOccurred())
+			fmt.Fprintf(GinkgoWriter, "Recorded %s seeded routes to %s\n", prefix, migration.SnapshotFile)
+		})
+	})
+
+	Context("after the migration", func() {
+		var (
+			snapshot migrationSnapshot
+		)
+
+		BeforeEach(func() {
+			if migration.Phase != helpers.VerifyMigrationPhase {
+				Skip("Skipping this test because Config.RoutingApiMigration.Phase is not verify.")
+			}
+
+			encoded, err := ioutil.ReadFile(migration.SnapshotFile)
+			Expect(err).ToNot(HaveOccurred(), "Run the seed phase before migrating the Routing API")
+
+			snapshot = migrationSnapshot{}
+			err = json.Unmarshal(encoded, &snapshot)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			if len(snapshot.Routes) > 0 {
+				err := routingApiClient.DeleteRoutes(snapshot.Routes)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			err := routingApiClient.DeleteTcpRouteMappings(snapshot.TcpRouteMappings)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = os.Remove(migration.SnapshotFile)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("preserves every route, router group and tcp route mapping with its tag", func() {
+			if len(snapshot.Routes) > 0 {
+				Expect(httpRoutesWithPrefix(snapshot.Prefix)).To(ConsistOf(snapshot.Routes))
+			}
+
+			routerGroups, err := routingApiClient.RouterGroups()
+			Expect(err).ToNot(HaveOccurred())
+			for _, routerGroup := range snapshot.RouterGroups {
+				Expect(routerGroups).To(ContainElement(routerGroup))
+			}
+
+			for _, mapping := range snapshot.TcpRouteMappings {
+				Expect(findTcpRouteMapping(mapping)).To(Equal(mapping))
+			}
+		})
+	})
+})
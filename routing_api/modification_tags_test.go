@@ -0,0 +1,134 @@
+package routing_api_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The Routing API does not compare the modification tag sent by a client
+// with the stored one: the last write wins and the stored tag keeps
+// advancing, so a writer holding a stale tag can never roll it back.
+var _ = Describe("Modification Tags", func() {
+	Context("HTTP routes", func() {
+		var (
+			route models.Route
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", 60)
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("applies an update carrying a stale tag and advances the stored tag", func() {
+			err := routingApiClient.UpsertRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+			stale := findHttpRoute(route)
+
+			outOfBand := stale
+			outOfBand.TTL = intPtr(90)
+			err = routingApiClient.UpsertRoutes([]models.Route{outOfBand})
+			Expect(err).ToNot(HaveOccurred())
+			current := findHttpRoute(route)
+			Expect(current.ModificationTag.Guid).To(Equal(stale.ModificationTag.Guid))
+			Expect(current.ModificationTag.Index).To(BeNumerically(">", stale.ModificationTag.Index))
+
+			staleWrite := stale
+			staleWrite.TTL = intPtr(120)
+			err = routingApiClient.UpsertRoutes([]models.Route{staleWrite})
+			Expect(err).ToNot(HaveOccurred())
+
+			final := findHttpRoute(route)
+			Expect(*final.TTL).To(Equal(120))
+			Expect(final.ModificationTag.Guid).To(Equal(current.ModificationTag.Guid))
+			Expect(final.ModificationTag.Index).To(BeNumerically(">", current.ModificationTag.Index))
+		})
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			mapping models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("applies an update carrying a stale tag and advances the stored tag", func() {
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+			stale := findTcpRouteMapping(mapping)
+
+			outOfBand := stale
+			outOfBand.TTL = intPtr(90)
+			err = routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{outOfBand})
+			Expect(err).ToNot(HaveOccurred())
+			current := findTcpRouteMapping(mapping)
+			Expect(current.ModificationTag.Guid).To(Equal(stale.ModificationTag.Guid))
+			Expect(current.ModificationTag.Index).To(BeNumerically(">", stale.ModificationTag.Index))
+
+			staleWrite := stale
+			staleWrite.TTL = intPtr(120)
+			err = routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{staleWrite})
+			Expect(err).ToNot(HaveOccurred())
+
+			final := findTcpRouteMapping(mapping)
+			Expect(*final.TTL).To(Equal(120))
+			Expect(final.ModificationTag.Guid).To(Equal(current.ModificationTag.Guid))
+			Expect(final.ModificationTag.Index).To(BeNumerically(">", current.ModificationTag.Index))
+		})
+	})
+})
+
+func findHttpRoute(route models.Route) models.Route {
+	routes, err := routingApiClient.Routes()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	for _, r := range routes {
+		if r.Route == route.Route && r.IP == route.IP && r.Port == route.Port {
+			return r
+		}
+	}
+	Fail(fmt.Sprintf("route %s is not listed", route.Route), 1)
+	return models.Route{}
+}
+
+func findTcpRouteMapping(mapping models.TcpRouteMapping) models.TcpRouteMapping {
+	mappings, err := routingApiClient.TcpRouteMappings()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	for _, m := range mappings {
+		if m.RouterGroupGuid == mapping.RouterGroupGuid && m.ExternalPort == mapping.ExternalPort &&
+			m.HostIP == mapping.HostIP && m.HostPort == mapping.HostPort {
+			return m
+		}
+	}
+	Fail(fmt.Sprintf("tcp route mapping for port %d is not listed", mapping.ExternalPort), 1)
+	return models.TcpRouteMapping{}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
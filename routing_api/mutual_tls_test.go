@@ -0,0 +1,55 @@
+package routing_api_test
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mutual TLS", func() {
+	var (
+		tlsConfig *helpers.RoutingApiTLSConfig
+		token     string
+	)
+
+	BeforeEach(func() {
+		tlsConfig = routingConfig.RoutingApiTLS
+		if tlsConfig == nil {
+			Skip("Skipping this test because Config.RoutingApiTLS is not set.")
+		}
+
+		uaaClient := helpers.NewUaaClient(routingConfig, logger)
+		fetched, err := uaaClient.FetchToken(true)
+		Expect(err).ToNot(HaveOccurred())
+		token = fetched.AccessToken
+	})
+
+	It("accepts clients presenting a trusted certificate", func() {
+		client := helpers.NewRoutingApiClient(routingConfig)
+		client.SetToken(token)
+
+		_, err := client.RouterGroups()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects clients that do not present a certificate", func() {
+		client := routing_api.NewClientWithTLSConfig(tlsConfig.ApiUrl, tlsConfig.ClientTLSConfig(false))
+		client.SetToken(token)
+
+		_, err := client.RouterGroups()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects plaintext clients", func() {
+		plaintextUrl := strings.Replace(tlsConfig.ApiUrl, "https://", "http://", 1)
+		client := routing_api.NewClient(plaintextUrl, false)
+		client.SetToken(token)
+
+		_, err := client.RouterGroups()
+		Expect(err).To(HaveOccurred())
+	})
+})
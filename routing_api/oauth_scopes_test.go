@@ -0,0 +1,144 @@
+package routing_api_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// scopedCall is one Routing API endpoint, called both through the client
+// and as a plain request so the status code can be checked too.
+type scopedCall struct {
+	name   string
+	write  bool
+	method string
+	path   string
+	body   interface{}
+	call   func(client routing_api.Client) error
+}
+
+var _ = Describe("OAuth Scopes", func() {
+	var (
+		route   models.Route
+		mapping models.TcpRouteMapping
+		calls   []scopedCall
+	)
+
+	BeforeEach(func() {
+		if routingConfig.ScopedOAuthClients == nil {
+			Skip("Skipping this test because Config.ScopedOAuthClients is not set.")
+		}
+
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", 60)
+		port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+		mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+
+		calls = []scopedCall{
+			{name: "list tcp route mappings", method: "GET", path: "/routing/v1/tcp_routes", call: func(c routing_api.Client) error {
+				_, err := c.TcpRouteMappings()
+				return err
+			}},
+			{name: "subscribe to tcp events", method: "GET", path: "/routing/v1/tcp_routes/events", call: func(c routing_api.Client) error {
+				source, err := c.SubscribeToTcpEventsWithMaxRetries(0)
+				if err == nil {
+					source.Close()
+				}
+				return err
+			}},
+			{name: "upsert tcp route mappings", write: true, method: "POST", path: "/routing/v1/tcp_routes/create", body: []models.TcpRouteMapping{mapping}, call: func(c routing_api.Client) error {
+				return c.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			}},
+			{name: "delete tcp route mappings", write: true, method: "POST", path: "/routing/v1/tcp_routes/delete", body: []models.TcpRouteMapping{mapping}, call: func(c routing_api.Client) error {
+				return c.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			}},
+		}
+
+		// the http route endpoints are only checked where they are enabled
+		if httpRoutesEnabled() {
+			calls = append(calls,
+				scopedCall{name: "list routes", method: "GET", path: "/routing/v1/routes", call: func(c routing_api.Client) error {
+					_, err := c.Routes()
+					return err
+				}},
+				scopedCall{name: "subscribe to http events", method: "GET", path: "/routing/v1/events", call: func(c routing_api.Client) error {
+					source, err := c.SubscribeToEventsWithMaxRetries(0)
+					if err == nil {
+						source.Close()
+					}
+					return err
+				}},
+				scopedCall{name: "upsert routes", write: true, method: "POST", path: "/routing/v1/routes", body: []models.Route{route}, call: func(c routing_api.Client) error {
+					return c.UpsertRoutes([]models.Route{route})
+				}},
+				scopedCall{name: "delete routes", write: true, method: "DELETE", path: "/routing/v1/routes", body: []models.Route{route}, call: func(c routing_api.Client) error {
+					return c.DeleteRoutes([]models.Route{route})
+				}},
+			)
+		}
+	})
+
+	AfterEach(func() {
+		if httpRoutesEnabled() {
+			err := routingApiClient.DeleteRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	expectScopes := func(client *helpers.OAuthClient, canRead, canWrite bool) {
+		if client == nil {
+			Skip("Skipping this test because the scoped client is not configured.")
+		}
+
+		scopedClient, token := newScopedRoutingApiClient(*client)
+		for _, c := range calls {
+			allowed := (c.write && canWrite) || (!c.write && canRead)
+			err := c.call(scopedClient)
+			if allowed {
+				Expect(err).ToNot(HaveOccurred(), c.name)
+				continue
+			}
+
+			Expect(err).To(HaveOccurred(), c.name)
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}), c.name)
+			Expect(err.(routing_api.Error).Type).To(Equal(routing_api.UnauthorizedError), c.name)
+
+			resp, _ := routingApiRequestWithToken(token, c.method, c.path, c.body)
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized), c.name)
+		}
+	}
+
+	It("allows only reads with routing.routes.read", func() {
+		expectScopes(routingConfig.ScopedOAuthClients.RoutesRead, true, false)
+	})
+
+	It("allows only writes with routing.routes.write", func() {
+		expectScopes(routingConfig.ScopedOAuthClients.RoutesWrite, false, true)
+	})
+
+	It("rejects every call without routing scopes", func() {
+		expectScopes(routingConfig.ScopedOAuthClients.NoRoutingScopes, false, false)
+	})
+})
+
+// newScopedRoutingApiClient returns a client authenticated as oauthClient,
+// along with its token.
+func newScopedRoutingApiClient(oauthClient helpers.OAuthClient) (routing_api.Client, string) {
+	uaaClient := helpers.NewUaaClientFor(routingConfig, oauthClient, logger)
+	token, err := uaaClient.FetchToken(true)
+	Expect(err).ToNot(HaveOccurred())
+
+	client := helpers.NewRoutingApiClient(routingConfig)
+	client.SetToken(token.AccessToken)
+	return client, token.AccessToken
+}
@@ -0,0 +1,70 @@
+package routing_api_test
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate Limiting", func() {
+	BeforeEach(func() {
+		if routingConfig.RoutingApiRateLimitBurst <= 0 {
+			Skip("Skipping this test because Config.RoutingApiRateLimitBurst is not set.")
+		}
+	})
+
+	It("answers a burst over the limit with 429 and Retry-After, then recovers", func() {
+		httpClient, baseUrl := helpers.NewRoutingApiHttpClient(routingConfig)
+
+		responses := make(chan *http.Response, routingConfig.RoutingApiRateLimitBurst)
+		wg := sync.WaitGroup{}
+		for i := 0; i < routingConfig.RoutingApiRateLimitBurst; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				req, err := http.NewRequest("GET", baseUrl+"/routing/v1/router_groups", nil)
+				Expect(err).ToNot(HaveOccurred())
+				req.Header.Set("Authorization", "bearer "+routingApiToken)
+
+				resp, err := httpClient.Do(req)
+				Expect(err).ToNot(HaveOccurred())
+				resp.Body.Close()
+				responses <- resp
+			}()
+		}
+		wg.Wait()
+		close(responses)
+
+		var limited []*http.Response
+		for resp := range responses {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				limited = append(limited, resp)
+			} else {
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}
+		}
+		Expect(limited).ToNot(BeEmpty(), "no request of the burst was rate limited")
+
+		var retryAfter time.Duration
+		for _, resp := range limited {
+			seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			Expect(err).ToNot(HaveOccurred(), "Retry-After must be a number of seconds")
+			Expect(seconds).To(BeNumerically(">=", 0))
+			if d := time.Duration(seconds) * time.Second; d > retryAfter {
+				retryAfter = d
+			}
+		}
+
+		time.Sleep(retryAfter)
+		_, err := routingApiClient.RouterGroups()
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,128 @@
+package routing_api_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const DUPLICATE_UPSERTS = 3
+
+// A tcp route mapping is identified by router group, external port and
+// backend, so the same external port in two router groups is two mappings.
+var _ = Describe("TCP Route Mapping Uniqueness", func() {
+	var (
+		routerGroup models.RouterGroup
+		mapping     models.TcpRouteMapping
+	)
+
+	BeforeEach(func() {
+		var err error
+		routerGroup, err = routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+		mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+	})
+
+	AfterEach(func() {
+		err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("within a router group", func() {
+		It("collapses repeated upserts into one entry and bumps its tag each time", func() {
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+			previous := findTcpRouteMapping(mapping)
+
+			for i := 0; i < DUPLICATE_UPSERTS; i++ {
+				err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+				Expect(err).ToNot(HaveOccurred())
+
+				current := findTcpRouteMapping(mapping)
+				Expect(current.ModificationTag.Guid).To(Equal(previous.ModificationTag.Guid))
+				Expect(current.ModificationTag.Index).To(Equal(previous.ModificationTag.Index + 1))
+				previous = current
+			}
+			Expect(tcpRouteMappingCount(mapping)).To(Equal(1))
+		})
+
+		It("collapses duplicates within one request into one entry", func() {
+			duplicates := []models.TcpRouteMapping{mapping, mapping, mapping}
+			err := routingApiClient.UpsertTcpRouteMappings(duplicates)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tcpRouteMappingCount(mapping)).To(Equal(1))
+		})
+	})
+
+	Context("across router groups", func() {
+		var (
+			otherGroup   models.RouterGroup
+			otherMapping models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeRouterGroupCreation {
+				Skip("Skipping this test because Config.IncludeRouterGroupCreation is set to `false`.")
+			}
+			capabilities.Require(helpers.RouterGroupCreationCapability)
+
+			name := fmt.Sprintf("rats-%s", helpers.RandomName())
+			err := routingApiClient.CreateRouterGroup(models.RouterGroup{
+				Name:            name,
+				Type:            models.RouterGroupType("tcp"),
+				ReservablePorts: models.ReservablePorts(fmt.Sprintf("%d", mapping.ExternalPort)),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			otherGroup, err = routingApiClient.RouterGroupWithName(name)
+			Expect(err).ToNot(HaveOccurred())
+
+			otherMapping = mapping
+			otherMapping.RouterGroupGuid = otherGroup.Guid
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{otherMapping})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = routingApiClient.DeleteRouterGroup(otherGroup)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps mappings for the same external port in each router group", func() {
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping, otherMapping})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tcpRouteMappingCount(mapping)).To(Equal(1))
+			Expect(tcpRouteMappingCount(otherMapping)).To(Equal(1))
+
+			err = routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{otherMapping})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tcpRouteMappingCount(mapping)).To(Equal(1))
+			Expect(tcpRouteMappingCount(otherMapping)).To(Equal(0))
+		})
+	})
+})
+
+// tcpRouteMappingCount counts the listed entries for the router group,
+// external port and backend of mapping.
+func tcpRouteMappingCount(mapping models.TcpRouteMapping) int {
+	mappings, err := routingApiClient.TcpRouteMappings()
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	count := 0
+	for _, m := range mappings {
+		if m.RouterGroupGuid == mapping.RouterGroupGuid && m.ExternalPort == mapping.ExternalPort &&
+			m.HostIP == mapping.HostIP && m.HostPort == mapping.HostPort {
+			count++
+		}
+	}
+	return count
+}
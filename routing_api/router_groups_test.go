@@ -0,0 +1,253 @@
+package routing_api_test
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Router Groups", func() {
+	var (
+		routerGroup models.RouterGroup
+	)
+
+	BeforeEach(func() {
+		var err error
+		routerGroup, err = routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("lists the configured tcp router group", func() {
+		routerGroups, err := routingApiClient.RouterGroups()
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+		for _, group := range routerGroups {
+			names = append(names, group.Name)
+			if group.Name == routingConfig.TCPRouterGroup {
+				Expect(group.Type).To(Equal(models.RouterGroupType("tcp")))
+				Expect(group.Guid).To(Equal(routerGroup.Guid))
+				Expect(group.ReservablePorts).ToNot(BeEmpty())
+			}
+		}
+		Expect(names).To(ContainElement(routingConfig.TCPRouterGroup))
+	})
+
+	Context("when updating reservable ports", func() {
+		var (
+			originalPorts models.ReservablePorts
+		)
+
+		BeforeEach(func() {
+			originalPorts = routerGroup.ReservablePorts
+		})
+
+		AfterEach(func() {
+			routerGroup.ReservablePorts = originalPorts
+			err := routingApiClient.UpdateRouterGroup(routerGroup)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("persists a valid port range", func() {
+			routerGroup.ReservablePorts = extendedReservablePorts(originalPorts)
+			err := routingApiClient.UpdateRouterGroup(routerGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			updated, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.ReservablePorts).To(Equal(routerGroup.ReservablePorts))
+		})
+
+		DescribeTable("rejects invalid port ranges without changing the router group",
+			func(reservablePorts string) {
+				routerGroup.ReservablePorts = models.ReservablePorts(reservablePorts)
+				err := routingApiClient.UpdateRouterGroup(routerGroup)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+				Expect(err.(routing_api.Error).Type).To(Equal(routing_api.ProcessRequestError))
+
+				current, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(current.ReservablePorts).To(Equal(originalPorts))
+			},
+			Entry("empty", ""),
+			Entry("not a number", "abc"),
+			Entry("start after end", "2000-1100"),
+			Entry("above the maximum port", "65000-70000"),
+			Entry("below the minimum port", "80-1100"),
+			Entry("overlapping ranges", "2000-3000,2500-3500"),
+		)
+	})
+
+	Context("when changing fields that cannot be updated", func() {
+		AfterEach(func() {
+			current, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current).To(Equal(routerGroup))
+		})
+
+		It("rejects renaming the router group", func() {
+			renamed := routerGroup
+			renamed.Name = fmt.Sprintf("rats-%s", helpers.RandomName())
+			err := routingApiClient.UpdateRouterGroup(renamed)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+			Expect(err.(routing_api.Error).Type).To(Equal(routing_api.NonUpdatableField))
+		})
+
+		It("rejects an invalid router group type", func() {
+			retyped := routerGroup
+			retyped.Type = models.RouterGroupType("udp")
+			err := routingApiClient.UpdateRouterGroup(retyped)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+			Expect(err.(routing_api.Error).Type).To(Equal(routing_api.NonUpdatableField))
+		})
+	})
+
+	Context("when mapping a tcp route to an http router group", func() {
+		var (
+			httpGroup models.RouterGroup
+			mapping   models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			routerGroups, err := routingApiClient.RouterGroups()
+			Expect(err).ToNot(HaveOccurred())
+
+			httpGroup = models.RouterGroup{}
+			for _, group := range routerGroups {
+				if group.Type == models.RouterGroupType("http") {
+					httpGroup = group
+					break
+				}
+			}
+			if httpGroup.Guid == "" {
+				Skip("Skipping this test because no http router group exists.")
+			}
+
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = models.NewTcpRouteMapping(httpGroup.Guid, port, "1.2.3.4", 60000, 60)
+		})
+
+		AfterEach(func() {
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("rejects the mapping", func() {
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+			Expect(tcpRouteMappingListed(mapping)).To(BeFalse())
+		})
+	})
+
+	Context("when creating a tcp router group", func() {
+		var (
+			newGroup    models.RouterGroup
+			extraGroups []models.RouterGroup
+		)
+
+		BeforeEach(func() {
+			extraGroups = nil
+			if !routingConfig.IncludeRouterGroupCreation {
+				Skip("Skipping this test because Config.IncludeRouterGroupCreation is set to `false`.")
+			}
+			capabilities.Require(helpers.RouterGroupCreationCapability)
+
+			newGroup = models.RouterGroup{
+				Name:            fmt.Sprintf("rats-%s", helpers.RandomName()),
+				Type:            models.RouterGroupType("tcp"),
+				ReservablePorts: models.ReservablePorts("60000-60010"),
+			}
+		})
+
+		AfterEach(func() {
+			if created, err := routingApiClient.RouterGroupWithName(newGroup.Name); err == nil {
+				err = routingApiClient.DeleteRouterGroup(created)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			for _, extra := range extraGroups {
+				err := routingApiClient.DeleteRouterGroup(extra)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("lists the new router group", func() {
+			err := routingApiClient.CreateRouterGroup(newGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			created, err := routingApiClient.RouterGroupWithName(newGroup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created.Guid).ToNot(BeEmpty())
+			Expect(created.Type).To(Equal(newGroup.Type))
+			Expect(created.ReservablePorts).To(Equal(newGroup.ReservablePorts))
+		})
+
+		It("rejects a name that is already taken", func() {
+			duplicate := newGroup
+			duplicate.Name = routingConfig.TCPRouterGroup
+			err := routingApiClient.CreateRouterGroup(duplicate)
+
+			// a duplicate the API wrongly accepted is found by listing, since
+			// looking it up by name may return either group
+			routerGroups, listErr := routingApiClient.RouterGroups()
+			Expect(listErr).ToNot(HaveOccurred())
+			var named []models.RouterGroup
+			for _, group := range routerGroups {
+				if group.Name == duplicate.Name {
+					named = append(named, group)
+					if group.Guid != routerGroup.Guid {
+						extraGroups = append(extraGroups, group)
+					}
+				}
+			}
+
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+			Expect(named).To(ConsistOf(routerGroup))
+		})
+
+		It("rejects an invalid router group type", func() {
+			newGroup.Type = models.RouterGroupType("udp")
+			err := routingApiClient.CreateRouterGroup(newGroup)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+			Expect(err.(routing_api.Error).Type).To(Equal(routing_api.ProcessRequestError))
+
+			_, err = routingApiClient.RouterGroupWithName(newGroup.Name)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+// extendedReservablePorts adds a small range next to the existing ones, leaving
+// every port that is already reservable in place.
+func extendedReservablePorts(reservablePorts models.ReservablePorts) models.ReservablePorts {
+	ranges, err := reservablePorts.Parse()
+	Expect(err).ToNot(HaveOccurred())
+
+	var lowest, highest uint64 = 65535, 0
+	for _, r := range ranges {
+		start, end := r.Endpoints()
+		if start < lowest {
+			lowest = start
+		}
+		if end > highest {
+			highest = end
+		}
+	}
+
+	if highest+10 <= 65535 {
+		return models.ReservablePorts(fmt.Sprintf("%s,%d-%d", reservablePorts, highest+1, highest+10))
+	}
+	Expect(lowest).To(BeNumerically(">=", 1024+10), "No room to extend the reservable ports")
+	return models.ReservablePorts(fmt.Sprintf("%s,%d-%d", reservablePorts, lowest-10, lowest-1))
+}
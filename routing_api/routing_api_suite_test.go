@@ -0,0 +1,112 @@
+package routing_api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	cf_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/helpers"
+)
+
+func TestRoutingApi(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	routingConfig = helpers.LoadConfig()
+
+	if routingConfig.DefaultTimeout > 0 {
+		DEFAULT_TIMEOUT = time.Duration(routingConfig.DefaultTimeout) * time.Second
+	}
+
+	componentName := "Routing API"
+
+	rs := []Reporter{}
+
+	if routingConfig.ArtifactsDirectory != "" {
+		rs = append(rs, cf_helpers.NewJUnitReporter(routingConfig.Config, componentName))
+	}
+
+	RunSpecsWithDefaultAndCustomReporters(t, componentName, rs)
+}
+
+var (
+	DEFAULT_TIMEOUT          = 2 * time.Minute
+	DEFAULT_POLLING_INTERVAL = 1 * time.Second
+
+	routingConfig    helpers.RoutingConfig
+	routingApiClient routing_api.Client
+	routingApiToken  string
+	capabilities     helpers.RoutingApiCapabilities
+	logger           lager.Logger
+)
+
+var _ = BeforeSuite(func() {
+	logger = lagertest.NewTestLogger("test")
+	routingApiClient = helpers.NewRoutingApiClient(routingConfig)
+
+	uaaClient := helpers.NewUaaClient(routingConfig, logger)
+	token, err := uaaClient.FetchToken(true)
+	Expect(err).ToNot(HaveOccurred())
+
+	routingApiToken = token.AccessToken
+	routingApiClient.SetToken(routingApiToken)
+	_, err = routingApiClient.RouterGroups()
+	Expect(err).ToNot(HaveOccurred(), "Routing API is unavailable")
+
+	capabilities = helpers.DiscoverRoutingApiCapabilities(routingConfig, routingApiToken)
+})
+
+// httpRoutesEnabled tells specs that also cover tcp route mappings whether to
+// exercise the http route endpoints too: they have to be configured and
+// offered by the Routing API under test.
+func httpRoutesEnabled() bool {
+	return routingConfig.IncludeHttpRoutes && capabilities[helpers.HttpRoutesCapability]
+}
+
+// routingApiRequest sends body as JSON to the Routing API and returns the
+// response along with its body, for specs that check more than the client
+// exposes.
+func routingApiRequest(method, path string, body interface{}) (*http.Response, []byte) {
+	return routingApiRequestWithToken(routingApiToken, method, path, body)
+}
+
+// routingApiRequestWithToken is routingApiRequest authenticated as another
+// client, for specs that check what that client's scopes allow.
+func routingApiRequestWithToken(token, method, path string, body interface{}) (*http.Response, []byte) {
+	encoded, err := json.Marshal(body)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return sendRoutingApiRequest(token, method, path, encoded)
+}
+
+// routingApiRawRequest sends body to the Routing API unchanged, so specs can
+// send payloads the client would never produce.
+func routingApiRawRequest(method, path string, body []byte) (*http.Response, []byte) {
+	return sendRoutingApiRequest(routingApiToken, method, path, body)
+}
+
+func sendRoutingApiRequest(token, method, path string, body []byte) (*http.Response, []byte) {
+	httpClient, baseUrl := helpers.NewRoutingApiHttpClient(routingConfig)
+	req, err := http.NewRequest(method, baseUrl+path, bytes.NewReader(body))
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	return resp, respBody
+}
@@ -0,0 +1,124 @@
+package routing_api_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TCP Route Events", func() {
+	var (
+		mapping models.TcpRouteMapping
+		sources []routing_api.TcpEventSource
+	)
+
+	subscribe := func() <-chan routing_api.TcpEvent {
+		source, events := subscribeToTcpEvents(mapping.ExternalPort)
+		sources = append(sources, source)
+		return events
+	}
+
+	BeforeEach(func() {
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+		mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, 60)
+		sources = nil
+	})
+
+	AfterEach(func() {
+		for _, source := range sources {
+			source.Close()
+		}
+		err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("delivers upsert and delete events in order with increasing modification tags", func() {
+		events := subscribe()
+
+		err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+		err = routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+		err = routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+
+		var created, refreshed, deleted routing_api.TcpEvent
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&created))
+		Expect(created.Action).To(Equal("Upsert"))
+		expectSameBackend(created.TcpRouteMapping, mapping)
+
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&refreshed))
+		Expect(refreshed.Action).To(Equal("Upsert"))
+		expectSameBackend(refreshed.TcpRouteMapping, mapping)
+		Expect(refreshed.TcpRouteMapping.ModificationTag.Guid).To(Equal(created.TcpRouteMapping.ModificationTag.Guid))
+		Expect(refreshed.TcpRouteMapping.ModificationTag.Index).To(BeNumerically(">", created.TcpRouteMapping.ModificationTag.Index))
+
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&deleted))
+		Expect(deleted.Action).To(Equal("Delete"))
+		expectSameBackend(deleted.TcpRouteMapping, mapping)
+
+		Consistently(events, 2*time.Second).ShouldNot(Receive())
+	})
+
+	// Reconnecting after the server ends the stream is not covered: the
+	// Routing API only checks a subscription's token when it is opened (see
+	// token_expiry_test.go), and nothing else in its public API makes it end
+	// an open stream short of restarting it. This spec covers the client
+	// half, that a fresh subscription picks up where a closed one left off.
+	It("delivers events to a new subscription after the client closes its previous one", func() {
+		events := subscribe()
+		err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive())
+
+		err = sources[0].Close()
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(events, DEFAULT_TIMEOUT).Should(BeClosed())
+
+		events = subscribe()
+		err = routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		Expect(err).ToNot(HaveOccurred())
+
+		var deleted routing_api.TcpEvent
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&deleted))
+		Expect(deleted.Action).To(Equal("Delete"))
+		expectSameBackend(deleted.TcpRouteMapping, mapping)
+	})
+})
+
+// subscribeToTcpEvents streams the tcp route events for externalPort. The
+// returned channel is closed once the event source is closed or fails.
+func subscribeToTcpEvents(externalPort uint16) (routing_api.TcpEventSource, <-chan routing_api.TcpEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	source, err := helpers.NewContextClient(routingApiClient).SubscribeToTcpEvents(ctx)
+	Expect(err).ToNot(HaveOccurred())
+
+	events := make(chan routing_api.TcpEvent, 100)
+	go func() {
+		defer close(events)
+		for event := range helpers.TcpEvents(context.Background(), source) {
+			if event.TcpRouteMapping.ExternalPort == externalPort {
+				events <- event
+			}
+		}
+	}()
+
+	return source, events
+}
+
+func expectSameBackend(actual, expected models.TcpRouteMapping) {
+	ExpectWithOffset(1, actual.RouterGroupGuid).To(Equal(expected.RouterGroupGuid))
+	ExpectWithOffset(1, actual.ExternalPort).To(Equal(expected.ExternalPort))
+	ExpectWithOffset(1, actual.HostIP).To(Equal(expected.HostIP))
+	ExpectWithOffset(1, actual.HostPort).To(Equal(expected.HostPort))
+}
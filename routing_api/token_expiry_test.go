@@ -0,0 +1,98 @@
+package routing_api_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+	uaaclient "code.cloudfoundry.org/uaa-go-client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The Routing API checks the token of an event subscription when it is
+// opened. The stream outlives the token, while new requests carrying it are
+// rejected.
+var _ = Describe("Token Expiry", func() {
+	var (
+		tokenValidity time.Duration
+		uaaClient     uaaclient.Client
+		client        routing_api.Client
+		route         models.Route
+		ctx           context.Context
+		cancel        context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		shortLived := routingConfig.ShortLivedOAuthClient
+		if shortLived == nil {
+			Skip("Skipping this test because Config.ShortLivedOAuthClient is not set.")
+		}
+		if !routingConfig.IncludeHttpRoutes {
+			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+		}
+		capabilities.Require(helpers.HttpRoutesCapability)
+		tokenValidity = time.Duration(shortLived.TokenValidity) * time.Second
+
+		uaaClient = helpers.NewUaaClientFor(routingConfig, shortLived.OAuthClient, logger)
+		client = helpers.NewRoutingApiClient(routingConfig)
+		route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", 60)
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+		err := routingApiClient.DeleteRoutes([]models.Route{route})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("keeps delivering events on a stream whose token has expired", func() {
+		token, err := uaaClient.FetchToken(true)
+		Expect(err).ToNot(HaveOccurred())
+		client.SetToken(token.AccessToken)
+		issued := time.Now()
+
+		source, err := helpers.NewContextClient(client).SubscribeToEvents(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		events := prefixedEvents(helpers.Events(ctx, source), route.Route)
+
+		time.Sleep(tokenValidity - time.Since(issued) + 5*time.Second)
+
+		_, err = client.Routes()
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+		Expect(err.(routing_api.Error).Type).To(Equal(routing_api.UnauthorizedError))
+
+		err = routingApiClient.UpsertRoutes([]models.Route{route})
+		Expect(err).ToNot(HaveOccurred())
+
+		var event routing_api.Event
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&event))
+		Expect(event.Action).To(Equal("Upsert"))
+	})
+
+	It("follows events across token expiry with the refreshing event stream", func() {
+		stream := helpers.NewRefreshingEventStream(ctx, client, uaaClient)
+		events := prefixedEvents(stream.Events(), route.Route)
+		Eventually(stream.Subscriptions, DEFAULT_TIMEOUT).Should(BeNumerically(">=", 1))
+
+		time.Sleep(tokenValidity + 5*time.Second)
+
+		// The first stream would outlive its token, so end it to make the
+		// stream subscribe again, which needs a fresh token.
+		stream.Interrupt()
+		Eventually(stream.Subscriptions, DEFAULT_TIMEOUT).Should(BeNumerically(">=", 2))
+
+		err := routingApiClient.UpsertRoutes([]models.Route{route})
+		Expect(err).ToNot(HaveOccurred())
+
+		var event routing_api.Event
+		Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&event))
+		Expect(event.Action).To(Equal("Upsert"))
+		fmt.Fprintf(GinkgoWriter, "\nRefreshing stream subscribed %d times over %s\n", stream.Subscriptions(), tokenValidity)
+	})
+})
@@ -0,0 +1,321 @@
+package routing_api_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	SHORT_TTL        = 10
+	HEARTBEAT_CYCLES = 5
+)
+
+var _ = Describe("Route TTLs", func() {
+	var (
+		ttl            time.Duration
+		pruneTolerance time.Duration
+	)
+
+	BeforeEach(func() {
+		ttl = SHORT_TTL * time.Second
+		pruneTolerance = time.Duration(routingConfig.RoutingApiPruneInterval) * time.Second
+	})
+
+	Context("HTTP routes", func() {
+		var (
+			route  models.Route
+			source routing_api.EventSource
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttpRoutes {
+				Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+			}
+			capabilities.Require(helpers.HttpRoutesCapability)
+
+			route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", SHORT_TTL)
+			source = nil
+		})
+
+		AfterEach(func() {
+			if source != nil {
+				source.Close()
+			}
+			err := routingApiClient.DeleteRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("expires a route that is not refreshed and emits an event for it", func() {
+			var events <-chan routing_api.Event
+			source, events = subscribeToHttpEvents(route.Route)
+
+			err := routingApiClient.UpsertRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+			registered := time.Now()
+
+			var upserted routing_api.Event
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&upserted))
+			Expect(upserted.Action).To(Equal("Upsert"))
+
+			Consistently(func() bool {
+				return httpRouteListed(route)
+			}, ttl-time.Since(registered)-time.Second, DEFAULT_POLLING_INTERVAL).Should(BeTrue())
+
+			Eventually(func() bool {
+				return httpRouteListed(route)
+			}, pruneTolerance+time.Second, DEFAULT_POLLING_INTERVAL).Should(BeFalse())
+			fmt.Fprintf(GinkgoWriter, "\nRoute with a %s TTL expired after %s\n", ttl, time.Since(registered))
+
+			var expired routing_api.Event
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&expired))
+			Expect(expired.Action).To(SatisfyAny(Equal("Delete"), Equal("Expire")))
+			Expect(expired.Route.Route).To(Equal(route.Route))
+		})
+
+		It("extends the lifetime of a route that is refreshed", func() {
+			err := routingApiClient.UpsertRoutes([]models.Route{route})
+			Expect(err).ToNot(HaveOccurred())
+
+			// An unrefreshed route would be pruned before the deadline
+			deadline := time.Now().Add(ttl + pruneTolerance)
+			for time.Now().Before(deadline) {
+				time.Sleep(ttl / 2)
+				err = routingApiClient.UpsertRoutes([]models.Route{route})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(httpRouteListed(route)).To(BeTrue())
+			}
+		})
+
+		// route-registrar heartbeats its routes at half their TTL and relies on
+		// them never dropping in between, and on them going away one TTL after
+		// it stops.
+		It("keeps a route heartbeated at half its TTL and expires it a TTL after the last heartbeat", func() {
+			var lastHeartbeat time.Time
+			for i := 0; i < HEARTBEAT_CYCLES; i++ {
+				err := routingApiClient.UpsertRoutes([]models.Route{route})
+				Expect(err).ToNot(HaveOccurred())
+				lastHeartbeat = time.Now()
+
+				Consistently(func() bool {
+					return httpRouteListed(route)
+				}, ttl/2, DEFAULT_POLLING_INTERVAL).Should(BeTrue(), "route dropped during heartbeat cycle %d", i)
+			}
+
+			Consistently(func() bool {
+				return httpRouteListed(route)
+			}, ttl-time.Since(lastHeartbeat)-time.Second, DEFAULT_POLLING_INTERVAL).Should(BeTrue())
+
+			Eventually(func() bool {
+				return httpRouteListed(route)
+			}, pruneTolerance+time.Second, DEFAULT_POLLING_INTERVAL).Should(BeFalse())
+
+			expiredAfter := time.Since(lastHeartbeat)
+			Expect(expiredAfter).To(BeNumerically(">=", ttl-time.Second))
+			fmt.Fprintf(GinkgoWriter, "\nRoute with a %s TTL expired %s after its last heartbeat\n", ttl, expiredAfter)
+		})
+	})
+
+	Context("TCP route mappings", func() {
+		var (
+			mapping models.TcpRouteMapping
+			source  routing_api.TcpEventSource
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, SHORT_TTL)
+			source = nil
+		})
+
+		AfterEach(func() {
+			if source != nil {
+				source.Close()
+			}
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("expires a mapping that is not refreshed and emits an event for it", func() {
+			var events <-chan routing_api.TcpEvent
+			source, events = subscribeToTcpEvents(mapping.ExternalPort)
+
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+			registered := time.Now()
+
+			var upserted routing_api.TcpEvent
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&upserted))
+			Expect(upserted.Action).To(Equal("Upsert"))
+
+			Consistently(func() bool {
+				return tcpRouteMappingListed(mapping)
+			}, ttl-time.Since(registered)-time.Second, DEFAULT_POLLING_INTERVAL).Should(BeTrue())
+
+			Eventually(func() bool {
+				return tcpRouteMappingListed(mapping)
+			}, pruneTolerance+time.Second, DEFAULT_POLLING_INTERVAL).Should(BeFalse())
+			fmt.Fprintf(GinkgoWriter, "\nMapping with a %s TTL expired after %s\n", ttl, time.Since(registered))
+
+			var expired routing_api.TcpEvent
+			Eventually(events, DEFAULT_TIMEOUT).Should(Receive(&expired))
+			Expect(expired.Action).To(SatisfyAny(Equal("Delete"), Equal("Expire")))
+			expectSameBackend(expired.TcpRouteMapping, mapping)
+		})
+
+		It("extends the lifetime of a mapping that is refreshed", func() {
+			err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+
+			// An unrefreshed mapping would be pruned before the deadline
+			deadline := time.Now().Add(ttl + pruneTolerance)
+			for time.Now().Before(deadline) {
+				time.Sleep(ttl / 2)
+				err = routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tcpRouteMappingListed(mapping)).To(BeTrue())
+			}
+		})
+	})
+
+	// The Routing API neither clamps nor defaults a TTL: anything outside
+	// 1 to max_ttl seconds is rejected, and nothing is written.
+	Context("TTL boundaries", func() {
+		var (
+			route   models.Route
+			mapping models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			route = models.NewRoute(fmt.Sprintf("%s.example.com", helpers.RandomName()), 65340, "1.2.3.4", "", "", SHORT_TTL)
+			mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, "1.2.3.4", 60000, SHORT_TTL)
+		})
+
+		AfterEach(func() {
+			if httpRoutesEnabled() {
+				err := routingApiClient.DeleteRoutes([]models.Route{route})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			err := routingApiClient.DeleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		DescribeTable("accepts or rejects http routes by TTL",
+			func(ttl func() int, accepted bool) {
+				if !routingConfig.IncludeHttpRoutes {
+					Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
+				}
+				capabilities.Require(helpers.HttpRoutesCapability)
+
+				route.TTL = intPtr(ttl())
+				err := routingApiClient.UpsertRoutes([]models.Route{route})
+				if accepted {
+					Expect(err).ToNot(HaveOccurred())
+					// a one second TTL may already have lapsed
+					if ttl() > 1 {
+						Expect(*findHttpRoute(route).TTL).To(Equal(ttl()))
+					}
+				} else {
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+					Expect(err.(routing_api.Error).Type).To(Equal(routing_api.RouteInvalidError))
+					Expect(httpRouteListed(route)).To(BeFalse())
+				}
+			},
+			Entry("negative", func() int { return -1 }, false),
+			Entry("zero", func() int { return 0 }, false),
+			Entry("one second", func() int { return 1 }, true),
+			Entry("the maximum", func() int { return routingConfig.RoutingApiMaxTTL }, true),
+			Entry("above the maximum", func() int { return routingConfig.RoutingApiMaxTTL + 1 }, false),
+		)
+
+		DescribeTable("accepts or rejects tcp route mappings by TTL",
+			func(ttl func() int, accepted bool) {
+				mapping.TTL = intPtr(ttl())
+				err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+				if accepted {
+					Expect(err).ToNot(HaveOccurred())
+					// a one second TTL may already have lapsed
+					if ttl() > 1 {
+						Expect(*findTcpRouteMapping(mapping).TTL).To(Equal(ttl()))
+					}
+				} else {
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(BeAssignableToTypeOf(routing_api.Error{}))
+					Expect(err.(routing_api.Error).Type).To(Equal(routing_api.TcpRouteMappingInvalidError))
+					Expect(tcpRouteMappingListed(mapping)).To(BeFalse())
+				}
+			},
+			Entry("negative", func() int { return -1 }, false),
+			Entry("zero", func() int { return 0 }, false),
+			Entry("one second", func() int { return 1 }, true),
+			Entry("the maximum", func() int { return routingConfig.RoutingApiMaxTTL }, true),
+			Entry("above the maximum", func() int { return routingConfig.RoutingApiMaxTTL + 1 }, false),
+		)
+	})
+})
+
+// subscribeToHttpEvents streams the http route events for routeName. The
+// returned channel is closed once the event source is closed or fails.
+func subscribeToHttpEvents(routeName string) (routing_api.EventSource, <-chan routing_api.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	source, err := helpers.NewContextClient(routingApiClient).SubscribeToEvents(ctx)
+	Expect(err).ToNot(HaveOccurred())
+
+	events := make(chan routing_api.Event, 100)
+	go func() {
+		defer close(events)
+		for {
+			event, err := source.Next()
+			if err != nil {
+				return
+			}
+			if event.Route.Route == routeName {
+				events <- event
+			}
+		}
+	}()
+
+	return source, events
+}
+
+func httpRouteListed(route models.Route) bool {
+	routes, err := routingApiClient.Routes()
+	Expect(err).ToNot(HaveOccurred())
+
+	for _, r := range routes {
+		if r.Route == route.Route && r.IP == route.IP && r.Port == route.Port {
+			return true
+		}
+	}
+	return false
+}
+
+func tcpRouteMappingListed(mapping models.TcpRouteMapping) bool {
+	mappings, err := routingApiClient.TcpRouteMappings()
+	Expect(err).ToNot(HaveOccurred())
+
+	for _, m := range mappings {
+		if m.RouterGroupGuid == mapping.RouterGroupGuid && m.ExternalPort == mapping.ExternalPort &&
+			m.HostIP == mapping.HostIP && m.HostPort == mapping.HostPort {
+			return true
+		}
+	}
+	return false
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 	"github.com/cloudfoundry-incubator/cf-test-helpers/generator"
 	cfworkflow_helpers "github.com/cloudfoundry-incubator/cf-test-helpers/workflowhelpers"
 
@@ -16,6 +19,11 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+const (
+	PORT_RANGE_SAMPLES       = 5
+	PORT_RESERVATION_RETRIES = 10
+)
+
 var routerIps []string
 var (
 	appName                 string
@@ -29,7 +37,11 @@ var (
 
 var _ = Describe("SmokeTests", func() {
 
+	var teardown *helpers.Teardown
+
 	BeforeEach(func() {
+		teardown = helpers.NewTeardown()
+
 		if routingConfig.TcpAppDomain != "" {
 			domainName = routingConfig.TcpAppDomain
 			cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
@@ -41,26 +53,38 @@ var _ = Describe("SmokeTests", func() {
 
 			cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
 				routing_helpers.CreateSharedDomain(domainName, routingConfig.TCPRouterGroup, DEFAULT_TIMEOUT)
+			})
+			sharedDomain := domainName
+			teardown.Add("delete shared domain "+sharedDomain, func() {
+				cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
+					routing_helpers.DeleteSharedDomain(sharedDomain, DEFAULT_TIMEOUT)
+				})
+			})
+
+			cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
 				routing_helpers.VerifySharedDomain(domainName, DEFAULT_TIMEOUT)
 			})
 			routerIps = routingConfig.Addresses
 		}
+
 		appName = routing_helpers.GenerateAppName()
+		app := appName
+		teardown.Add("delete app "+app, func() {
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		})
+		teardown.Add("report app "+app, func() {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+		})
+
 		helpers.UpdateOrgQuota(adminContext)
 	})
 
 	AfterEach(func() {
-		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
-		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
-		if routingConfig.TcpAppDomain == "" {
-			cfworkflow_helpers.AsUser(adminContext, adminContext.Timeout, func() {
-				routing_helpers.DeleteSharedDomain(domainName, DEFAULT_TIMEOUT)
-			})
-		}
+		Expect(teardown.Run()).NotTo(HaveOccurred())
 	})
 
 	It("map tcp route to app successfully ", func() {
-		routing_helpers.PushAppNoStart(appName, tcpSampleGolang, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "--no-route", "-s", "cflinuxfs3")
+		helpers.PushGoAppNoStart(routingConfig, appName, tcpSampleGolang, "", CF_PUSH_TIMEOUT, "256M", "", "--no-route", "-s", "cflinuxfs3")
 		routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
 		routing_helpers.MapRandomTcpRouteToApp(appName, domainName, DEFAULT_TIMEOUT)
 		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
@@ -78,8 +102,93 @@ var _ = Describe("SmokeTests", func() {
 			curlAppFailure(routingAddr, port)
 		}
 	})
+
+	It("routes tcp traffic on ports sampled across the router group's reservable range", func() {
+		routerGroup, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).NotTo(HaveOccurred())
+
+		helpers.PushGoAppNoStart(routingConfig, appName, tcpSampleGolang, "", CF_PUSH_TIMEOUT, "256M", "", "--no-route", "-s", "cflinuxfs3")
+		routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+
+		reservablePorts := expandReservablePorts(routerGroup.ReservablePorts)
+		var mappedPorts []string
+		for _, offset := range samplePortOffsets(len(reservablePorts), PORT_RANGE_SAMPLES) {
+			port, ok := mapTcpRouteNear(appName, domainName, reservablePorts, offset)
+			Expect(ok).To(BeTrue(), fmt.Sprintf("Unable to map a tcp route near port %d", reservablePorts[offset]))
+			mappedPorts = append(mappedPorts, port)
+			teardown.Add("delete tcp route "+port, func() {
+				routing_helpers.DeleteTcpRoute(domainName, port, DEFAULT_TIMEOUT)
+			})
+		}
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+		// check every sampled port is forwarded by all Addresses
+		for _, port := range mappedPorts {
+			for _, routingAddr := range routerIps {
+				curlAppSuccess(routingAddr, port)
+			}
+		}
+	})
 })
 
+// expandReservablePorts flattens the router group's reservable ranges into an
+// ordered list of ports.
+func expandReservablePorts(reservablePorts models.ReservablePorts) []uint16 {
+	ranges, err := reservablePorts.Parse()
+	Expect(err).NotTo(HaveOccurred())
+
+	var ports []uint16
+	for _, r := range ranges {
+		start, end := r.Endpoints()
+		for port := start; port <= end; port++ {
+			ports = append(ports, uint16(port))
+		}
+	}
+	Expect(ports).NotTo(BeEmpty(), "Router group has no reservable ports")
+	return ports
+}
+
+// samplePortOffsets returns evenly spaced offsets into a list of total ports,
+// always including the first and the last one.
+func samplePortOffsets(total, samples int) []int {
+	if samples > total {
+		samples = total
+	}
+	if samples < 2 {
+		return []int{0}
+	}
+
+	var offsets []int
+	for i := 0; i < samples; i++ {
+		offsets = append(offsets, i*(total-1)/(samples-1))
+	}
+	return offsets
+}
+
+// mapTcpRouteNear maps a tcp route on the port at offset, moving towards the
+// middle of the range when the port is already reserved by someone else.
+func mapTcpRouteNear(appName, domainName string, ports []uint16, offset int) (string, bool) {
+	step := 1
+	if offset >= len(ports)/2 {
+		step = -1
+	}
+
+	for i := 0; i < PORT_RESERVATION_RETRIES; i++ {
+		candidate := offset + i*step
+		if candidate < 0 || candidate >= len(ports) {
+			break
+		}
+
+		port := strconv.Itoa(int(ports[candidate]))
+		session := cf.Cf("map-route", appName, domainName, "--port", port).Wait(DEFAULT_TIMEOUT)
+		if session.ExitCode() == 0 {
+			return port, true
+		}
+		fmt.Fprintf(GinkgoWriter, "\nUnable to map port %s, trying the next one\n", port)
+	}
+	return "", false
+}
+
 func curlAppSuccess(domainName, port string) {
 	appUrl := fmt.Sprintf("http://%s:%s", domainName, port)
 	fmt.Fprintf(GinkgoWriter, "\nConnecting to URL %s... \n", appUrl)
@@ -21,6 +21,7 @@ var (
 	DEFAULT_POLLING_INTERVAL = 5 * time.Second
 	CF_PUSH_TIMEOUT          = 2 * time.Minute
 	routingConfig            helpers.RoutingConfig
+	routingApiClient         routing_api.Client
 	environment              *cfworkflow_helpers.ReproducibleTestSuiteSetup
 )
 
@@ -56,7 +57,7 @@ var _ = BeforeSuite(func() {
 	environment.Setup()
 
 	logger := lagertest.NewTestLogger("test")
-	routingApiClient := routing_api.NewClient(routingConfig.RoutingApiUrl, routingConfig.SkipSSLValidation)
+	routingApiClient = helpers.NewRoutingApiClient(routingConfig)
 
 	uaaClient := helpers.NewUaaClient(routingConfig, logger)
 	token, err := uaaClient.FetchToken(true)
@@ -69,6 +70,10 @@ var _ = BeforeSuite(func() {
 })
 
 var _ = AfterSuite(func() {
-	environment.Teardown()
-	CleanupBuildArtifacts()
+	teardown := helpers.NewTeardown()
+	teardown.Add("cleanup build artifacts", CleanupBuildArtifacts)
+	teardown.Add("teardown environment", func() {
+		environment.Teardown()
+	})
+	Expect(teardown.Run()).NotTo(HaveOccurred())
 })
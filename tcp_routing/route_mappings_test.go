@@ -0,0 +1,658 @@
+package tcp_routing_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	MAPPING_TTL        = 300
+	MAPPING_BATCH_SIZE = 100
+	MAPPING_SAMPLES    = 10
+)
+
+// These specs write tcp route mappings straight to the Routing API. The
+// mappings point at the backend of an app pushed through Cloud Controller, so
+// the router has something real to forward to.
+var _ = Describe("Tcp Route Mappings", func() {
+	var (
+		appName            string
+		tcpDropletReceiver = assets.NewAssets().TcpDropletReceiver
+		serverId           string
+		externalPort       uint16
+		routerGroup        models.RouterGroup
+		backend            models.TcpRouteMapping
+	)
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "server1"
+		cmd := fmt.Sprintf("tcp-droplet-receiver --serverId=%s", serverId)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		helpers.PushGoAppNoStart(routingConfig, appName, tcpDropletReceiver, "", CF_PUSH_TIMEOUT, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
+		routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+		routing_helpers.UpdatePorts(appName, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.CreateRouteMapping(appName, "", externalPort, 3333, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+		var err error
+		routerGroup, err = routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+		Expect(err).ToNot(HaveOccurred())
+
+		backend = waitForTcpBackends(externalPort, 1)[0]
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteTcpRoute(domainName, fmt.Sprintf("%d", externalPort), DEFAULT_TIMEOUT)
+	})
+
+	Context("with a large number of external port mappings", func() {
+		var (
+			mappings []models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			if !routingConfig.IncludeTcpMappingScale {
+				Skip("Skipping this test because Config.IncludeTcpMappingScale is set to `false`.")
+			}
+
+			mappings = nil
+			for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, routingConfig.TcpMappingScaleCount) {
+				mappings = append(mappings, backendMapping(routerGroup.Guid, port, backend))
+			}
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings(mappings)
+		})
+
+		It("serves every mapping within the configured time and keeps serving existing ports", func() {
+			timeout := time.Duration(routingConfig.TcpMappingScaleTimeout) * time.Second
+
+			start := time.Now()
+			upsertTcpRouteMappings(mappings)
+
+			sampledPorts := sampleExternalPorts(mappings, MAPPING_SAMPLES)
+			for _, routerAddr := range routingConfig.Addresses {
+				for _, port := range sampledPorts {
+					Eventually(func() (string, error) {
+						return sendAndReceive(routerAddr, port)
+					}, timeout, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+				}
+			}
+
+			elapsed := time.Since(start)
+			fmt.Fprintf(GinkgoWriter, "\nRouters served %d mappings after %s\n", len(mappings), elapsed)
+			Expect(elapsed).To(BeNumerically("<", timeout))
+
+			ports := append(sampledPorts, externalPort)
+			for _, routerAddr := range routingConfig.Addresses {
+				for _, port := range ports {
+					resp, err := sendAndReceive(routerAddr, port)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp).To(ContainSubstring(serverId))
+				}
+			}
+		})
+	})
+
+	Context("when the same mappings are requested more than once", func() {
+		var (
+			mappings []models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			mappings = nil
+			for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, 3) {
+				mappings = append(mappings, backendMapping(routerGroup.Guid, port, backend))
+			}
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings(mappings)
+		})
+
+		It("keeps a single mapping when an identical request is repeated", func() {
+			for i := 0; i < 5; i++ {
+				upsertTcpRouteMappings(mappings[:1])
+			}
+			upsertTcpRouteMappings([]models.TcpRouteMapping{mappings[0], mappings[0]})
+
+			port := mappings[0].ExternalPort
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, port)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+			}
+			Expect(tcpRouteMappingsForPort(port)).To(HaveLen(1))
+		})
+
+		It("converges to one mapping per port when requests overlap", func() {
+			upsertTcpRouteMappings(mappings[:2])
+			upsertTcpRouteMappings(mappings[1:])
+
+			for _, mapping := range mappings {
+				port := mapping.ExternalPort
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() (string, error) {
+						return sendAndReceive(routerAddr, port)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+				}
+
+				portMappings := tcpRouteMappingsForPort(port)
+				Expect(portMappings).To(HaveLen(1))
+				Expect(portMappings[0].HostIP).To(Equal(backend.HostIP))
+				Expect(portMappings[0].HostPort).To(Equal(backend.HostPort))
+			}
+
+			// the app's own port is untouched by the overlapping requests
+			Expect(tcpRouteMappingsForPort(externalPort)).To(HaveLen(1))
+		})
+	})
+
+	Context("when mappings are deleted", func() {
+		var (
+			mappings []models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			mappings = nil
+			for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, 2) {
+				mappings = append(mappings, backendMapping(routerGroup.Guid, port, backend))
+			}
+			upsertTcpRouteMappings(mappings)
+
+			for _, mapping := range mappings {
+				port := mapping.ExternalPort
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() (string, error) {
+						return sendAndReceive(routerAddr, port)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+				}
+			}
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings(mappings)
+		})
+
+		It("stops routing the removed port and leaves other mappings alone", func() {
+			removedPort := mappings[0].ExternalPort
+			remainingPort := mappings[1].ExternalPort
+			deleteTcpRouteMappings(mappings[:1])
+
+			Expect(tcpRouteMappingsForPort(removedPort)).To(BeEmpty())
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() error {
+					_, err := sendAndReceive(routerAddr, removedPort)
+					return err
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveOccurred())
+
+				for _, port := range []uint16{remainingPort, externalPort} {
+					resp, err := sendAndReceive(routerAddr, port)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resp).To(ContainSubstring(serverId))
+				}
+			}
+		})
+
+		It("accepts deleting a mapping that does not exist", func() {
+			deleteTcpRouteMappings(mappings[:1])
+
+			err := routingApiClient.DeleteTcpRouteMappings(mappings[:1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tcpRouteMappingsForPort(mappings[1].ExternalPort)).To(HaveLen(1))
+		})
+	})
+
+	// The Routing API ignores the modification tags clients send (see
+	// routing_api/modification_tags_test.go), so the stale-tag race between
+	// emitters cannot be driven through the public API. Instead the two
+	// emitters write the same mapping with different TTLs, which tells their
+	// writes apart: the routers must keep serving it while they race, and the
+	// Routing API must settle on one of the writes once they stop.
+	Context("when two emitters race on the same mapping", func() {
+		const raceDuration = 10 * time.Second
+
+		var (
+			mapping models.TcpRouteMapping
+			writes  []models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = backendMapping(routerGroup.Guid, port, backend)
+			writes = []models.TcpRouteMapping{
+				mapping,
+				models.NewTcpRouteMapping(routerGroup.Guid, port, backend.HostIP, backend.HostPort, MAPPING_TTL/2),
+			}
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		})
+
+		It("keeps serving a single mapping while the writes race and then stops changing", func() {
+			port := mapping.ExternalPort
+			upsertTcpRouteMappings(writes[:1])
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, port)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+			}
+
+			ttls := func(mappings []models.TcpRouteMapping) []int {
+				var result []int
+				for _, m := range mappings {
+					result = append(result, *m.TTL)
+				}
+				return result
+			}
+			writtenTTLs := ttls(writes)
+
+			errs := make(chan error, len(writes))
+			done := make(chan struct{})
+			deadline := time.Now().Add(raceDuration)
+			wg := sync.WaitGroup{}
+			for _, written := range writes {
+				wg.Add(1)
+				go func(m models.TcpRouteMapping) {
+					defer wg.Done()
+					for time.Now().Before(deadline) {
+						err := routingApiClient.UpsertTcpRouteMappings([]models.TcpRouteMapping{m})
+						if err != nil {
+							errs <- err
+							return
+						}
+					}
+				}(written)
+			}
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			samples := 0
+			for racing := true; racing; {
+				select {
+				case <-done:
+					racing = false
+				default:
+				}
+
+				mappings := tcpRouteMappingsForPort(port)
+				Expect(mappings).To(HaveLen(1))
+				Expect(writtenTTLs).To(ContainElement(*mappings[0].TTL))
+				for _, routerAddr := range routingConfig.Addresses {
+					Expect(sendAndReceive(routerAddr, port)).To(ContainSubstring(serverId))
+				}
+				samples++
+			}
+			close(errs)
+			for err := range errs {
+				Expect(err).ToNot(HaveOccurred())
+			}
+			fmt.Fprintf(GinkgoWriter, "\nSampled the racing mapping %d times\n", samples)
+
+			winner := waitForTcpBackends(port, 1)[0]
+			Expect(writtenTTLs).To(ContainElement(*winner.TTL))
+			Consistently(func() []int {
+				return ttls(tcpRouteMappingsForPort(port))
+			}, 10*time.Second, time.Second).Should(Equal([]int{*winner.TTL}))
+		})
+	})
+
+	Context("when the TTL of a mapping lapses", func() {
+		const shortTTL = 10
+
+		var (
+			mapping models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = models.NewTcpRouteMapping(routerGroup.Guid, port, backend.HostIP, backend.HostPort, shortTTL)
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		})
+
+		It("prunes the mapping from the Routing API and the routers", func() {
+			port := mapping.ExternalPort
+			upsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+			registered := time.Now()
+
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, port)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+			}
+
+			pruneTimeout := shortTTL*time.Second + time.Duration(routingConfig.RoutingApiPruneInterval)*time.Second
+			waitForTcpBackends(port, 0)
+			Expect(time.Since(registered)).To(BeNumerically("<", pruneTimeout+DEFAULT_POLLING_INTERVAL))
+			fmt.Fprintf(GinkgoWriter, "\nRouting API pruned mapping on port %d %s after registration\n", port, time.Since(registered))
+
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() error {
+					_, err := sendAndReceive(routerAddr, port)
+					return err
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveOccurred())
+				fmt.Fprintf(GinkgoWriter, "\nRouter %s stopped serving port %d %s after registration\n", routerAddr, port, time.Since(registered))
+			}
+
+			// the app's own mapping is refreshed by the emitter and must survive
+			Expect(tcpRouteMappingsForPort(externalPort)).To(HaveLen(1))
+		})
+	})
+
+	Context("when routing data is lost and restored from a backup", func() {
+		var (
+			mappings []models.TcpRouteMapping
+			snapshot *helpers.RoutingSnapshot
+		)
+
+		BeforeEach(func() {
+			snapshot = nil
+			if !routingConfig.IncludeRoutingDataLoss {
+				Skip("Skipping this test because Config.IncludeRoutingDataLoss is set to `false`.")
+			}
+
+			mappings = nil
+			for _, port := range helpers.UnusedExternalPorts(routingApiClient, routerGroup, 3) {
+				mappings = append(mappings, backendMapping(routerGroup.Guid, port, backend))
+			}
+			upsertTcpRouteMappings(mappings)
+		})
+
+		AfterEach(func() {
+			// puts the spec's mappings back even when it failed after wiping
+			// them
+			if snapshot != nil {
+				snapshot.Restore(routingApiClient)
+			}
+			deleteTcpRouteMappings(mappings)
+		})
+
+		It("serves the restored mappings again", func() {
+			backup := helpers.TakeRoutingSnapshot(routingApiClient, routingConfig.IncludeHttpRoutes)
+			expectBackendsListed(backup.TcpRouteMappings, mappings)
+			if routingConfig.ArtifactsDirectory != "" {
+				backup.Save(filepath.Join(routingConfig.ArtifactsDirectory, fmt.Sprintf("routing_snapshot_%d.json", GinkgoParallelNode())))
+			}
+
+			// only the spec's own mappings are lost and restored, so other
+			// nodes' mappings are neither deleted nor brought back stale
+			scoped := backup.Only(mappings)
+			snapshot = &scoped
+			Expect(snapshot.TcpRouteMappings).To(HaveLen(len(mappings)))
+
+			snapshot.Wipe(routingApiClient)
+			for _, mapping := range mappings {
+				Expect(tcpRouteMappingsForPort(mapping.ExternalPort)).To(BeEmpty())
+			}
+
+			restored := time.Now()
+			snapshot.Restore(routingApiClient)
+			listed, err := routingApiClient.TcpRouteMappings()
+			Expect(err).ToNot(HaveOccurred())
+			expectBackendsListed(listed, snapshot.TcpRouteMappings)
+
+			for _, routerAddr := range routingConfig.Addresses {
+				for _, mapping := range mappings {
+					Eventually(func() (string, error) {
+						return sendAndReceive(routerAddr, mapping.ExternalPort)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+				}
+			}
+			fmt.Fprintf(GinkgoWriter, "\nRouters serving restored mappings %s after restore\n", time.Since(restored))
+		})
+	})
+
+	// Shrinking the reservable range grandfathers mappings on ports outside
+	// it: the Routing API accepts the update without touching them, and the
+	// routers keep forwarding to them.
+	Context("when the reservable range shrinks below a port in use", func() {
+		var (
+			originalPorts models.ReservablePorts
+		)
+
+		BeforeEach(func() {
+			originalPorts = routerGroup.ReservablePorts
+		})
+
+		AfterEach(func() {
+			routerGroup.ReservablePorts = originalPorts
+			err := routingApiClient.UpdateRouterGroup(routerGroup)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps the existing mapping and its traffic", func() {
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, externalPort)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+			}
+
+			routerGroup.ReservablePorts = reservablePortsWithout(originalPorts, externalPort)
+			err := routingApiClient.UpdateRouterGroup(routerGroup)
+			Expect(err).ToNot(HaveOccurred())
+
+			updated, err := routingApiClient.RouterGroupWithName(routingConfig.TCPRouterGroup)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.ReservablePorts).To(Equal(routerGroup.ReservablePorts))
+			Expect(backendAddresses(tcpRouteMappingsForPort(externalPort))).To(ConsistOf(backendAddresses([]models.TcpRouteMapping{backend})))
+
+			Consistently(func() error {
+				for _, routerAddr := range routingConfig.Addresses {
+					if _, err := sendAndReceive(routerAddr, externalPort); err != nil {
+						return fmt.Errorf("router %s: %s", routerAddr, err)
+					}
+				}
+				return nil
+			}, 30*time.Second, time.Second).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("when several router addresses are configured", func() {
+		var (
+			mapping models.TcpRouteMapping
+		)
+
+		BeforeEach(func() {
+			if len(routingConfig.Addresses) < 2 {
+				Skip("Skipping this test because only one router address is configured.")
+			}
+			port := helpers.UnusedExternalPorts(routingApiClient, routerGroup, 1)[0]
+			mapping = backendMapping(routerGroup.Guid, port, backend)
+		})
+
+		AfterEach(func() {
+			deleteTcpRouteMappings([]models.TcpRouteMapping{mapping})
+		})
+
+		It("converges every router instance on a mapping applied once", func() {
+			port := mapping.ExternalPort
+			start := time.Now()
+			upsertTcpRouteMappings([]models.TcpRouteMapping{mapping})
+
+			converged := make(chan string, len(routingConfig.Addresses))
+			for _, routerAddr := range routingConfig.Addresses {
+				go func(addr string) {
+					defer GinkgoRecover()
+					Eventually(func() (string, error) {
+						return sendAndReceive(addr, port)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+					fmt.Fprintf(GinkgoWriter, "\nRouter %s serving port %d after %s\n", addr, port, time.Since(start))
+					converged <- addr
+				}(routerAddr)
+			}
+
+			var servingRouters []string
+			for range routingConfig.Addresses {
+				var addr string
+				Eventually(converged, DEFAULT_TIMEOUT+DEFAULT_POLLING_INTERVAL).Should(Receive(&addr))
+				servingRouters = append(servingRouters, addr)
+			}
+			Expect(servingRouters).To(ConsistOf(routingConfig.Addresses))
+
+			// once converged, no router instance falls back to an older configuration
+			Consistently(func() error {
+				for _, routerAddr := range routingConfig.Addresses {
+					if _, err := sendAndReceive(routerAddr, port); err != nil {
+						return fmt.Errorf("router %s: %s", routerAddr, err)
+					}
+				}
+				return nil
+			}, 10*time.Second, time.Second).ShouldNot(HaveOccurred())
+		})
+	})
+})
+
+func tcpRouteMappingsForPort(externalPort uint16) []models.TcpRouteMapping {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+	defer cancel()
+	mappings, err := helpers.NewContextClient(routingApiClient).TcpRouteMappings(ctx)
+	Expect(err).ToNot(HaveOccurred())
+
+	var result []models.TcpRouteMapping
+	for _, mapping := range mappings {
+		if mapping.ExternalPort == externalPort {
+			result = append(result, mapping)
+		}
+	}
+	return result
+}
+
+// waitForTcpBackends waits until the Routing API lists exactly count backends
+// for externalPort and returns their mappings. Specs use it to know the routing
+// table is in the expected state before they start sampling connections.
+func waitForTcpBackends(externalPort uint16, count int) []models.TcpRouteMapping {
+	var mappings []models.TcpRouteMapping
+	Eventually(func() []models.TcpRouteMapping {
+		mappings = tcpRouteMappingsForPort(externalPort)
+		return mappings
+	}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(count))
+	return mappings
+}
+
+// backendAddresses returns the host ip:port of every mapping.
+func backendAddresses(mappings []models.TcpRouteMapping) []string {
+	var addresses []string
+	for _, mapping := range mappings {
+		addresses = append(addresses, fmt.Sprintf("%s:%d", mapping.HostIP, mapping.HostPort))
+	}
+	return addresses
+}
+
+// expectBackendsListed checks every expected mapping has a listed counterpart
+// with the same router group, external port and backend.
+func expectBackendsListed(listed, expected []models.TcpRouteMapping) {
+	keys := func(mappings []models.TcpRouteMapping) []string {
+		var result []string
+		for _, mapping := range mappings {
+			result = append(result, fmt.Sprintf("%s:%d->%s:%d", mapping.RouterGroupGuid, mapping.ExternalPort, mapping.HostIP, mapping.HostPort))
+		}
+		return result
+	}
+
+	listedKeys := keys(listed)
+	for _, key := range keys(expected) {
+		ExpectWithOffset(1, listedKeys).To(ContainElement(key))
+	}
+}
+
+// reservablePortsWithout carves port out of the reservable ranges, leaving
+// every other reservable port in place.
+func reservablePortsWithout(reservablePorts models.ReservablePorts, port uint16) models.ReservablePorts {
+	ranges, err := reservablePorts.Parse()
+	Expect(err).ToNot(HaveOccurred())
+
+	var parts []string
+	appendRange := func(start, end uint64) {
+		if start == end {
+			parts = append(parts, fmt.Sprintf("%d", start))
+		} else if start < end {
+			parts = append(parts, fmt.Sprintf("%d-%d", start, end))
+		}
+	}
+	for _, r := range ranges {
+		start, end := r.Endpoints()
+		if uint64(port) < start || uint64(port) > end {
+			appendRange(start, end)
+			continue
+		}
+		appendRange(start, uint64(port)-1)
+		appendRange(uint64(port)+1, end)
+	}
+	Expect(parts).ToNot(BeEmpty(), "Port %d is the only reservable port", port)
+	return models.ReservablePorts(strings.Join(parts, ","))
+}
+
+// backendMapping maps externalPort to the same backend as an existing mapping.
+func backendMapping(routerGroupGuid string, externalPort uint16, backend models.TcpRouteMapping) models.TcpRouteMapping {
+	return models.NewTcpRouteMapping(routerGroupGuid, externalPort, backend.HostIP, backend.HostPort, MAPPING_TTL)
+}
+
+func sampleExternalPorts(mappings []models.TcpRouteMapping, samples int) []uint16 {
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	step := len(mappings) / samples
+	if step == 0 {
+		step = 1
+	}
+
+	var ports []uint16
+	for i := 0; i < len(mappings); i += step {
+		ports = append(ports, mappings[i].ExternalPort)
+	}
+	last := mappings[len(mappings)-1].ExternalPort
+	if ports[len(ports)-1] != last {
+		ports = append(ports, last)
+	}
+	return ports
+}
+
+func upsertTcpRouteMappings(mappings []models.TcpRouteMapping) {
+	for start := 0; start < len(mappings); start += MAPPING_BATCH_SIZE {
+		end := start + MAPPING_BATCH_SIZE
+		if end > len(mappings) {
+			end = len(mappings)
+		}
+		err := routingApiClient.UpsertTcpRouteMappings(mappings[start:end])
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
+
+func deleteTcpRouteMappings(mappings []models.TcpRouteMapping) {
+	for start := 0; start < len(mappings); start += MAPPING_BATCH_SIZE {
+		end := start + MAPPING_BATCH_SIZE
+		if end > len(mappings) {
+			end = len(mappings)
+		}
+		err := routingApiClient.DeleteTcpRouteMappings(mappings[start:end])
+		Expect(err).ToNot(HaveOccurred())
+	}
+}
@@ -62,7 +62,7 @@ var (
 
 var _ = BeforeSuite(func() {
 	logger = lagertest.NewTestLogger("test")
-	routingApiClient = routing_api.NewClient(routingConfig.RoutingApiUrl, routingConfig.SkipSSLValidation)
+	routingApiClient = helpers.NewRoutingApiClient(routingConfig)
 
 	uaaClient := helpers.NewUaaClient(routingConfig, logger)
 	token, err := uaaClient.FetchToken(true)
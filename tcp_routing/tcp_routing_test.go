@@ -1,9 +1,15 @@
 package tcp_routing_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,9 +17,11 @@ import (
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"github.com/cloudfoundry-incubator/cf-test-helpers/cf"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
 )
 
 var _ = Describe("Tcp Routing", func() {
@@ -38,11 +46,12 @@ var _ = Describe("Tcp Routing", func() {
 			externalPort1 = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
 
 			// Uses --no-route flag so there is no HTTP route
-			routing_helpers.PushAppNoStart(appName, tcpDropletReceiver, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs3")
+			helpers.PushGoAppNoStart(routingConfig, appName, tcpDropletReceiver, "", CF_PUSH_TIMEOUT, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
 			routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
 			routing_helpers.UpdatePorts(appName, []uint16{3333}, DEFAULT_TIMEOUT)
 			routing_helpers.CreateRouteMapping(appName, "", externalPort1, 3333, DEFAULT_TIMEOUT)
 			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+			waitForTcpBackends(externalPort1, 1)
 		})
 
 		AfterEach(func() {
@@ -75,11 +84,15 @@ var _ = Describe("Tcp Routing", func() {
 				cmd := fmt.Sprintf("tcp-droplet-receiver --serverId=%s", serverId2)
 
 				// Uses --no-route flag so there is no HTTP route
-				routing_helpers.PushAppNoStart(secondAppName, tcpDropletReceiver, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs3")
+				helpers.PushGoAppNoStart(routingConfig, secondAppName, tcpDropletReceiver, "", CF_PUSH_TIMEOUT, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
 				routing_helpers.EnableDiego(secondAppName, DEFAULT_TIMEOUT)
 				routing_helpers.UpdatePorts(secondAppName, []uint16{3333}, DEFAULT_TIMEOUT)
+				// A process health check keeps Diego from restarting the instance
+				// while it refuses connections, leaving that to the tcp router.
+				Expect(cf.Cf("set-health-check", secondAppName, "process").Wait(DEFAULT_TIMEOUT)).To(Exit(0))
 				routing_helpers.CreateRouteMapping(secondAppName, "", externalPort1, 3333, DEFAULT_TIMEOUT)
 				routing_helpers.StartApp(secondAppName, DEFAULT_TIMEOUT)
+				waitForTcpBackends(externalPort1, 2)
 			})
 
 			AfterEach(func() {
@@ -108,6 +121,51 @@ var _ = Describe("Tcp Routing", func() {
 					Expect(serverResponses()).To(ContainElement(serverId2))
 				}
 			})
+
+			// Two apps requesting the same external port share it: the router
+			// load balances across the backends of both instead of rejecting either.
+			It("registers a backend for each app and balances across both", func() {
+				backends := backendAddresses(waitForTcpBackends(externalPort1, 2))
+				Expect(backends[0]).ToNot(Equal(backends[1]))
+
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() ([]string, error) {
+						return getUniqueServerResponses(routerAddr, externalPort1, 20)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ConsistOf(serverId1, serverId2))
+				}
+			})
+
+			It("stops routing to a backend failing its health check and rebalances once it recovers", func() {
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() ([]string, error) {
+						return getServerResponses(routerAddr, externalPort1, 10)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(SatisfyAll(
+						ContainElement(serverId1),
+						ContainElement(serverId2),
+					))
+				}
+
+				signalReceiverProcess(secondAppName, 0, "tcp-droplet-receiver", "USR1")
+
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() ([]string, error) {
+						return getServerResponses(routerAddr, externalPort1, 10)
+					}, time.Duration(routingConfig.TcpUnhealthyBackendTimeout)*time.Second, DEFAULT_POLLING_INTERVAL).Should(SatisfyAll(
+						ContainElement(serverId1),
+						Not(ContainElement(serverId2)),
+					))
+				}
+
+				signalReceiverProcess(secondAppName, 0, "tcp-droplet-receiver", "USR2")
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() ([]string, error) {
+						return getServerResponses(routerAddr, externalPort1, 10)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(SatisfyAll(
+						ContainElement(serverId1),
+						ContainElement(serverId2),
+					))
+				}
+			})
 		})
 
 		Context("when multiple external ports are mapped to a single app port", func() {
@@ -135,6 +193,239 @@ var _ = Describe("Tcp Routing", func() {
 			})
 		})
 
+		Context("when mappings are added while connections are streaming", func() {
+			const reloadCount = 3
+
+			var (
+				addedPorts []uint16
+			)
+
+			BeforeEach(func() {
+				addedPorts = nil
+			})
+
+			AfterEach(func() {
+				for _, port := range addedPorts {
+					routing_helpers.DeleteTcpRoute(domainName, fmt.Sprintf("%d", port), DEFAULT_TIMEOUT)
+				}
+			})
+
+			It("does not drop existing connections while the router reloads its configuration", func() {
+				stop := make(chan struct{})
+				var streams []<-chan error
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() error {
+						_, err := sendAndReceive(routerAddr, externalPort1)
+						return err
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+
+					conn, err := net.DialTimeout(CONN_TYPE, fmt.Sprintf("%s:%d", routerAddr, externalPort1), DEFAULT_CONNECT_TIMEOUT)
+					Expect(err).ToNot(HaveOccurred())
+					defer conn.Close()
+					streams = append(streams, streamMessages(conn, stop))
+				}
+
+				// every new mapping forces the router to regenerate and reload its configuration
+				for i := 0; i < reloadCount; i++ {
+					port := routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+					addedPorts = append(addedPorts, port)
+					routing_helpers.CreateRouteMapping(appName, "", port, 3333, DEFAULT_TIMEOUT)
+
+					for _, routerAddr := range routingConfig.Addresses {
+						Eventually(func() (string, error) {
+							return sendAndReceive(routerAddr, port)
+						}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId1))
+					}
+				}
+
+				close(stop)
+				for _, stream := range streams {
+					Eventually(stream, DEFAULT_TIMEOUT).Should(Receive(BeNil()))
+				}
+			})
+		})
+
+	})
+
+	Context("scaled app", func() {
+		var (
+			appName            string
+			tcpDropletReceiver = assets.NewAssets().TcpDropletReceiver
+			externalPort       uint16
+		)
+
+		BeforeEach(func() {
+			appName = routing_helpers.GenerateAppName()
+			// Every instance echoes its own index so connections can be attributed to instances
+			cmd := "tcp-droplet-receiver --serverId=instance-$CF_INSTANCE_INDEX"
+			spaceName := environment.RegularUserContext().Space
+			externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+			// Uses --no-route flag so there is no HTTP route
+			helpers.PushGoAppNoStart(routingConfig, appName, tcpDropletReceiver, "", CF_PUSH_TIMEOUT, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
+			routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+			routing_helpers.UpdatePorts(appName, []uint16{3333}, DEFAULT_TIMEOUT)
+			routing_helpers.CreateRouteMapping(appName, "", externalPort, 3333, DEFAULT_TIMEOUT)
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		AfterEach(func() {
+			routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteTcpRoute(domainName, fmt.Sprintf("%d", externalPort), DEFAULT_TIMEOUT)
+		})
+
+		It("tracks the running instances in the routing table and across connections", func() {
+			for _, instances := range []int{1, 3, 1} {
+				start := time.Now()
+				scaleApp(appName, instances)
+
+				waitForTcpBackends(externalPort, instances)
+
+				var expectedServerIds []string
+				for i := 0; i < instances; i++ {
+					expectedServerIds = append(expectedServerIds, fmt.Sprintf("instance-%d", i))
+				}
+
+				for _, routerAddr := range routingConfig.Addresses {
+					Eventually(func() ([]string, error) {
+						return getUniqueServerResponses(routerAddr, externalPort, 10*instances)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ConsistOf(expectedServerIds))
+				}
+
+				fmt.Fprintf(GinkgoWriter, "\nRouting converged on %d instances after %s\n", instances, time.Since(start))
+			}
+		})
+
+		It("keeps held connections open while the app scales up", func() {
+			waitForTcpBackends(externalPort, 1)
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, externalPort)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring("instance-0"))
+			}
+
+			reportDir, err := ioutil.TempDir("", "connection-holder")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(reportDir)
+
+			var holders []*Session
+			for i, routerAddr := range routingConfig.Addresses {
+				holder, err := Start(exec.Command(connectionHolderPath(),
+					"-target", fmt.Sprintf("%s:%d", routerAddr, externalPort),
+					"-connections", "10",
+					"-duration", DEFAULT_TIMEOUT.String(),
+					"-output", filepath.Join(reportDir, fmt.Sprintf("drops-%d.json", i)),
+				), GinkgoWriter, GinkgoWriter)
+				Expect(err).ToNot(HaveOccurred())
+				holders = append(holders, holder)
+			}
+
+			// adding backends makes every router apply a new configuration
+			scaleApp(appName, 3)
+			waitForTcpBackends(externalPort, 3)
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() ([]string, error) {
+					return getUniqueServerResponses(routerAddr, externalPort, 30)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ConsistOf("instance-0", "instance-1", "instance-2"))
+			}
+
+			for i, holder := range holders {
+				holder.Interrupt()
+				Eventually(holder, DEFAULT_TIMEOUT).Should(Exit(0))
+
+				encoded, err := ioutil.ReadFile(filepath.Join(reportDir, fmt.Sprintf("drops-%d.json", i)))
+				Expect(err).ToNot(HaveOccurred())
+				var report connectionHolderReport
+				Expect(json.Unmarshal(encoded, &report)).To(Succeed())
+				Expect(report.Drops).To(BeEmpty(), "connections through %s dropped while scaling up", routingConfig.Addresses[i])
+			}
+		})
+
+		It("replaces the backend of a crashed instance once Diego reschedules it", func() {
+			scaleApp(appName, 2)
+			original := backendAddresses(waitForTcpBackends(externalPort, 2))
+
+			start := time.Now()
+			killReceiverProcess(appName, 1, "tcp-droplet-receiver")
+
+			remainingOriginal := func() []string {
+				var remaining []string
+				for _, backend := range backendAddresses(tcpRouteMappingsForPort(externalPort)) {
+					for _, o := range original {
+						if backend == o {
+							remaining = append(remaining, backend)
+						}
+					}
+				}
+				return remaining
+			}
+			Eventually(remainingOriginal, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(1))
+			removedAfter := time.Since(start)
+
+			// Diego may restart the instance in place on the same host port, so
+			// the replacement is only known by the backend count coming back
+			waitForTcpBackends(externalPort, 2)
+			readdedAfter := time.Since(start)
+
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() ([]string, error) {
+					return getUniqueServerResponses(routerAddr, externalPort, 20)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ConsistOf("instance-0", "instance-1"))
+			}
+
+			fmt.Fprintf(GinkgoWriter, "\nCrashed backend removed after %s, replacement added after %s\n", removedAfter, readdedAfter)
+		})
+	})
+
+	Context("app start latency", func() {
+		var (
+			appName            string
+			tcpDropletReceiver = assets.NewAssets().TcpDropletReceiver
+			serverId           string
+			externalPort       uint16
+		)
+
+		BeforeEach(func() {
+			appName = routing_helpers.GenerateAppName()
+			serverId = "server1"
+			cmd := fmt.Sprintf("tcp-droplet-receiver --serverId=%s", serverId)
+			spaceName := environment.RegularUserContext().Space
+			externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+			// Uses --no-route flag so there is no HTTP route
+			helpers.PushGoAppNoStart(routingConfig, appName, tcpDropletReceiver, "", CF_PUSH_TIMEOUT, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
+			routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
+			routing_helpers.UpdatePorts(appName, []uint16{3333}, DEFAULT_TIMEOUT)
+			routing_helpers.CreateRouteMapping(appName, "", externalPort, 3333, DEFAULT_TIMEOUT)
+
+			// Start once so the app is staged, leaving only the LRP to be desired when measuring
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+			stopApp(appName)
+			waitForTcpBackends(externalPort, 0)
+		})
+
+		AfterEach(func() {
+			routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteTcpRoute(domainName, fmt.Sprintf("%d", externalPort), DEFAULT_TIMEOUT)
+		})
+
+		Measure("time from app start to the first tcp connection", func(b Benchmarker) {
+			budget := time.Duration(routingConfig.TcpFirstConnectionBudget) * time.Second
+
+			start := time.Now()
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+			for _, routerAddr := range routingConfig.Addresses {
+				Eventually(func() (string, error) {
+					return sendAndReceive(routerAddr, externalPort)
+				}, budget, 100*time.Millisecond).Should(ContainSubstring(serverId))
+			}
+			elapsed := time.Since(start)
+
+			b.RecordValue("seconds to first tcp connection", elapsed.Seconds())
+			Expect(elapsed).To(BeNumerically("<", budget))
+		}, 1)
 	})
 
 	Context("multiple-app ports", func() {
@@ -159,7 +450,7 @@ var _ = Describe("Tcp Routing", func() {
 			externalPort1 = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
 
 			// Uses --no-route flag so there is no HTTP route
-			routing_helpers.PushAppNoStart(appName, tcpSampleReceiver, routingConfig.GoBuildpackName, "", 2*time.Minute, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs3")
+			helpers.PushGoAppNoStart(routingConfig, appName, tcpSampleReceiver, "", 2*time.Minute, "256M", cmd, "--no-route", "-s", "cflinuxfs3")
 			routing_helpers.EnableDiego(appName, DEFAULT_TIMEOUT)
 			routing_helpers.UpdatePorts(appName, []uint16{appPort1, appPort2}, DEFAULT_TIMEOUT)
 			routing_helpers.CreateRouteMapping(appName, "", externalPort1, appPort1, DEFAULT_TIMEOUT)
@@ -245,8 +536,67 @@ const (
 	DEFAULT_RW_TIMEOUT      = 2 * time.Second
 	CONN_TYPE               = "tcp"
 	BUFFER_SIZE             = 1024
+	STREAM_INTERVAL         = 200 * time.Millisecond
 )
 
+// killReceiverProcess kills the receiver inside the app container without
+// telling Diego, simulating a backend that dies while its route is registered.
+func killReceiverProcess(appName string, instance int, process string) {
+	signalReceiverProcess(appName, instance, process, "TERM")
+}
+
+// signalReceiverProcess sends signal to the receiver inside the app
+// container. The tcp-droplet-receiver refuses new connections on USR1 and
+// accepts them again on USR2.
+func signalReceiverProcess(appName string, instance int, process string, signal string) {
+	// The bracket keeps pkill from matching the shell running the command.
+	pattern := fmt.Sprintf("%s[%s]", process[:len(process)-1], process[len(process)-1:])
+	command := fmt.Sprintf("pkill -%s -f '%s'", signal, pattern)
+	ExpectWithOffset(1, cf.Cf("ssh", appName, "-i", strconv.Itoa(instance), "-c", command).Wait(DEFAULT_TIMEOUT)).To(Exit(0))
+}
+
+// streamMessages keeps exchanging messages over conn until stop is closed.
+// The returned channel receives the first error encountered, or nil when the
+// connection stayed healthy for the whole time.
+func streamMessages(conn net.Conn, stop <-chan struct{}) <-chan error {
+	result := make(chan error, 1)
+
+	go func() {
+		buff := make([]byte, BUFFER_SIZE)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				result <- nil
+				return
+			case <-time.After(STREAM_INTERVAL):
+			}
+
+			message := fmt.Sprintf("stream message %d", i)
+			err := conn.SetDeadline(time.Now().Add(DEFAULT_RW_TIMEOUT))
+			if err == nil {
+				_, err = conn.Write([]byte(message))
+			}
+
+			var n int
+			if err == nil {
+				n, err = conn.Read(buff)
+			}
+
+			if err == nil && !strings.Contains(string(buff[:n]), message) {
+				err = fmt.Errorf("unexpected response %q to %q", string(buff[:n]), message)
+			}
+
+			if err != nil {
+				logger.Error("stream-failed", err, lager.Data{"address": conn.RemoteAddr()})
+				result <- err
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
 func getServerResponse(addr string, externalPort uint16) (string, error) {
 	response, err := sendAndReceive(addr, externalPort)
 	if err != nil {
@@ -259,6 +609,64 @@ func getServerResponse(addr string, externalPort uint16) (string, error) {
 	return tokens[0], nil
 }
 
+func getServerResponses(addr string, externalPort uint16, count int) ([]string, error) {
+	var servers []string
+	for i := 0; i < count; i++ {
+		srv, err := getServerResponse(addr, externalPort)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, srv)
+	}
+	return servers, nil
+}
+
+func getUniqueServerResponses(addr string, externalPort uint16, count int) ([]string, error) {
+	servers, err := getServerResponses(addr, externalPort, count)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var unique []string
+	for _, server := range servers {
+		if !seen[server] {
+			seen[server] = true
+			unique = append(unique, server)
+		}
+	}
+	return unique, nil
+}
+
+func stopApp(appName string) {
+	Expect(cf.Cf("stop", appName).Wait(DEFAULT_TIMEOUT)).To(Exit(0))
+}
+
+// connectionHolderReport is the part of the cmd/connection-holder report
+// the specs check.
+type connectionHolderReport struct {
+	Drops []struct {
+		Connection int       `json:"connection"`
+		DroppedAt  time.Time `json:"dropped_at"`
+		Error      string    `json:"error"`
+	} `json:"drops"`
+}
+
+// connectionHolderPath is the connection-holder binary the bin scripts build
+// once per run into $CONNECTION_HOLDER, or a fresh build of it otherwise.
+func connectionHolderPath() string {
+	if path := os.Getenv("CONNECTION_HOLDER"); path != "" {
+		return path
+	}
+	path, err := Build("code.cloudfoundry.org/routing-acceptance-tests/cmd/connection-holder")
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	return path
+}
+
+func scaleApp(appName string, instances int) {
+	Expect(cf.Cf("scale", appName, "-i", strconv.Itoa(instances)).Wait(DEFAULT_TIMEOUT)).To(Exit(0))
+}
+
 func sendAndReceive(addr string, externalPort uint16) (string, error) {
 	address := fmt.Sprintf("%s:%d", addr, externalPort)
 